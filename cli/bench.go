@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"context"        // Import the package for cancelling the run
+	"fmt"            // Import the package for formatted input/output
+	"os"             // Import the package for writing to standard output
+	"os/signal"      // Import the package for handling interrupts
+	"text/tabwriter" // Import the package for aligned table output
+	"time"           // Import the package for working with time
+
+	"nats_practice/nats_basic" // Import the package with the benchmark runner
+)
+
+// Function to run a throughput and latency benchmark
+func benchCommand(args []string) error {
+	fs, server := newFlagSet("bench")
+	mode := fs.String("mode", nats_basic.BenchPubSub, "pubsub, queue, request or jetstream")
+	pubs := fs.Int("pub", 1, "number of publishers")
+	subs := fs.Int("sub", 1, "number of subscribers")
+	count := fs.Int("count", nats_basic.DefaultBenchCount, "messages sent by every publisher")
+	size := fs.Int("size", nats_basic.DefaultBenchSize, "bytes per message, at least 8")
+	rate := fs.Int("rate", 0, "messages per second of every publisher, 0 for unlimited")
+	timeout := fs.Duration("timeout", nats_basic.DefaultBenchTimeout, "request or stream ack timeout and how long to wait for subscribers")
+	asJSON := fs.Bool("json", false, "print JSON instead of a table")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("usage: bench [-mode m] [-pub n] [-sub n] [-count n] [-size n] [-rate n] [-timeout d] [-json] [subject]")
+	}
+
+	// Stop publishing on Ctrl-C and report what was measured so far
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	report, err := nats_basic.RunBench(ctx, nats_basic.BenchOptions{
+		URL:         *server,
+		Mode:        *mode,
+		Subject:     fs.Arg(0),
+		Publishers:  *pubs,
+		Subscribers: *subs,
+		Count:       *count,
+		Size:        *size,
+		Rate:        *rate,
+		Timeout:     *timeout,
+	})
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(report)
+	}
+
+	// Print one row per client, followed by the totals of the publishers and the subscribers
+	fmt.Printf("%s benchmark on %q with %d byte messages\n\n", report.Mode, report.Subject, report.Size)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CLIENT\tMSGS\tERRORS\tDURATION\tMSGS/SEC\tMB/SEC\tP50\tP99\tP999")
+	rows := append([]nats_basic.BenchResult(nil), report.Publishers...)
+	rows = append(rows, report.PublisherTotal)
+	if len(report.Subscribers) > 0 {
+		rows = append(rows, report.Subscribers...)
+		rows = append(rows, report.SubscriberTotal)
+	}
+	for _, r := range rows {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%.0f\t%.2f\t%s\t%s\t%s\n", r.Client, r.Msgs, r.Errors,
+			r.Duration.Round(time.Millisecond), r.MsgsPerSec, r.MBPerSec, benchLatency(r.P50), benchLatency(r.P99), benchLatency(r.P999))
+	}
+	return tw.Flush()
+}
+
+// Function to format a latency, leaving it empty when it was not measured
+func benchLatency(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.Round(time.Microsecond).String()
+}
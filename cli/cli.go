@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"encoding/json" // Import the package for JSON output
+	"flag"          // Import the package for parsing command-line flags
+	"fmt"           // Import the package for formatted input/output
+	"os"            // Import the package for working with environment variables
+	"sort"          // Import the package for sorting
+	"strings"       // Import the package for working with strings
+
+	"github.com/nats-io/nats.go" // Import the package for working with NATS
+)
+
+// Signature of a top-level command handler
+type command func(args []string) error
+
+// Registered top-level commands, keyed by name
+var commands = map[string]command{
+	"bench":    benchCommand,
+	"config":   configCommand,
+	"consumer": consumerCommand,
+	"kv":       kvCommand,
+	"objstore": objstoreCommand,
+	"service":  serviceCommand,
+	"stream":   streamCommand,
+}
+
+// Function to run the command named by the first argument
+func Run(args []string) error {
+	if len(args) == 0 {
+		return usageError()
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown command %q\n%s", args[0], usage())
+	}
+	return cmd(args[1:])
+}
+
+// Function to build the top-level usage text
+func usage() string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return "usage: nats_practice <command> [arguments]\ncommands: " + strings.Join(names, ", ")
+}
+
+// Function to build an error holding the top-level usage text
+func usageError() error {
+	return fmt.Errorf("%s", usage())
+}
+
+// Function to create a flag set with the common connection flags
+func newFlagSet(name string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+
+	// Default to NATS_URL from the environment, then to the local server
+	defaultURL := os.Getenv("NATS_URL")
+	if defaultURL == "" {
+		defaultURL = nats.DefaultURL
+	}
+	server := fs.String("server", defaultURL, "NATS server URL")
+	return fs, server
+}
+
+// Function to connect to NATS and create a JetStream context
+func connectJetStream(server string) (*nats.Conn, nats.JetStreamContext, error) {
+	nc, err := nats.Connect(server)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connect to %s: %w", server, err)
+	}
+
+	js, err := nc.JetStream()
+	if err != nil {
+		nc.Close() // Do not leak the connection if JetStream is unavailable
+		return nil, nil, fmt.Errorf("create JetStream context: %w", err)
+	}
+	return nc, js, nil
+}
+
+// Function to print a value as indented JSON on standard output
+func printJSON(v any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// Function to print a value as compact JSON on a single line
+func printJSONLine(v any) error {
+	return json.NewEncoder(os.Stdout).Encode(v)
+}
@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"bufio"   // Import the package for reading files line by line
+	"errors"  // Import the package for working with errors
+	"fmt"     // Import the package for formatted input/output
+	"os"      // Import the package for opening files
+	"sort"    // Import the package for sorting
+	"strings" // Import the package for working with strings
+
+	"github.com/nats-io/nats.go" // Import the package for working with NATS
+
+	"nats_practice/nats_basic" // Import the package with the config helpers
+)
+
+// Function to dispatch the config subcommands
+func configCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: config <get|set|diff> [arguments]")
+	}
+
+	switch args[0] {
+	case "get":
+		return configGet(args[1:])
+	case "set":
+		return configSet(args[1:])
+	case "diff":
+		return configDiff(args[1:])
+	default:
+		return fmt.Errorf("unknown config subcommand %q", args[0])
+	}
+}
+
+// Function to print every setting of a config bucket
+func configGet(args []string) error {
+	fs, server := newFlagSet("config get")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: config get [-server url] <bucket>")
+	}
+
+	nc, js, err := connectJetStream(*server)
+	if err != nil {
+		return err
+	}
+	defer nc.Close() // Close the connection when the command completes
+
+	kv, err := js.KeyValue(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("open bucket %q: %w", fs.Arg(0), err)
+	}
+
+	values, err := nats_basic.ReadConfigValues(kv)
+	if err != nil {
+		return err
+	}
+
+	// Print the settings sorted by key
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Printf("%s=%s\n", key, values[key])
+	}
+	return nil
+}
+
+// Function to write one or more key=value settings, creating the bucket when needed
+func configSet(args []string) error {
+	fs, server := newFlagSet("config set")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 2 {
+		return fmt.Errorf("usage: config set [-server url] <bucket> <key=value>...")
+	}
+
+	values := map[string]string{}
+	for _, pair := range fs.Args()[1:] {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("invalid setting %q, expected key=value", pair)
+		}
+		values[key] = value
+	}
+
+	nc, js, err := connectJetStream(*server)
+	if err != nil {
+		return err
+	}
+	defer nc.Close() // Close the connection when the command completes
+
+	kv, err := js.KeyValue(fs.Arg(0))
+	if errors.Is(err, nats.ErrBucketNotFound) {
+		kv, err = nats_basic.CreateKVBucket(js, nats_basic.KVBucketOptions{
+			Bucket:  fs.Arg(0),
+			History: nats_basic.KVHistory,
+			Storage: nats.FileStorage,
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("open bucket %q: %w", fs.Arg(0), err)
+	}
+
+	return nats_basic.WriteConfigValues(kv, values)
+}
+
+// Function to show how a file of key=value lines differs from the settings in a bucket
+func configDiff(args []string) error {
+	fs, server := newFlagSet("config diff")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: config diff [-server url] <bucket> <file>")
+	}
+
+	desired, err := readConfigFile(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	nc, js, err := connectJetStream(*server)
+	if err != nil {
+		return err
+	}
+	defer nc.Close() // Close the connection when the command completes
+
+	kv, err := js.KeyValue(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("open bucket %q: %w", fs.Arg(0), err)
+	}
+
+	current, err := nats_basic.ReadConfigValues(kv)
+	if err != nil {
+		return err
+	}
+
+	// Print one line per difference in a diff-like format
+	for _, change := range nats_basic.DiffConfigValues(current, desired) {
+		switch change.Kind {
+		case nats_basic.ConfigAdded:
+			fmt.Printf("+ %s=%s\n", change.Key, change.New)
+		case nats_basic.ConfigRemoved:
+			fmt.Printf("- %s=%s\n", change.Key, change.Old)
+		case nats_basic.ConfigChanged:
+			fmt.Printf("~ %s=%s -> %s\n", change.Key, change.Old, change.New)
+		}
+	}
+	return nil
+}
+
+// Function to read key=value lines from a file, skipping blank lines and # comments
+func readConfigFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() // Close the file when reading completes
+
+	values := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected key=value", path, line)
+		}
+		values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return values, scanner.Err()
+}
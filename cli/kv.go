@@ -0,0 +1,446 @@
+package cli
+
+import (
+	"context"   // Import the package for cancellation
+	"fmt"       // Import the package for formatted input/output
+	"io"        // Import the package for reading standard input
+	"os"        // Import the package for working with standard streams
+	"os/signal" // Import the package for handling interrupts
+	"strconv"   // Import the package for parsing revisions
+	"time"      // Import the package for working with time
+
+	"github.com/nats-io/nats.go" // Import the package for working with NATS
+
+	"nats_practice/nats_basic" // Import the package with the key-value helpers
+)
+
+// Key-value entry as printed by the kv commands
+type kvEntryView struct {
+	Bucket    string    `json:"bucket"`    // Bucket the entry belongs to
+	Key       string    `json:"key"`       // Key of the entry
+	Value     string    `json:"value"`     // Value of the entry
+	Revision  uint64    `json:"revision"`  // Revision of the entry
+	Created   time.Time `json:"created"`   // Time the entry was written
+	Operation string    `json:"operation"` // PUT, DEL or PURGE
+}
+
+// Function to convert a key-value entry for printing
+func viewEntry(entry nats.KeyValueEntry) kvEntryView {
+	return kvEntryView{
+		Bucket:    entry.Bucket(),
+		Key:       entry.Key(),
+		Value:     string(entry.Value()),
+		Revision:  entry.Revision(),
+		Created:   entry.Created(),
+		Operation: nats_basic.OperationName(entry.Operation()),
+	}
+}
+
+// Function to print a key-value entry as text or as a single JSON line
+func printEntry(entry nats.KeyValueEntry, asJSON bool) error {
+	view := viewEntry(entry)
+	if asJSON {
+		return printJSONLine(view)
+	}
+	fmt.Printf("%s revision=%d op=%s created=%s value=%s\n",
+		view.Key, view.Revision, view.Operation, view.Created.Format(time.RFC3339Nano), view.Value)
+	return nil
+}
+
+// Function to print a revision as text or JSON after a write
+func printRevision(key string, revision uint64, asJSON bool) error {
+	if asJSON {
+		return printJSONLine(map[string]any{"key": key, "revision": revision})
+	}
+	fmt.Printf("%s revision=%d\n", key, revision)
+	return nil
+}
+
+// Function to dispatch the kv subcommands
+func kvCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: kv <get|put|create|update|del|purge|keys|history|watch|status> [arguments]")
+	}
+
+	switch args[0] {
+	case "get":
+		return kvGet(args[1:])
+	case "put":
+		return kvPut(args[1:])
+	case "create":
+		return kvCreate(args[1:])
+	case "update":
+		return kvUpdate(args[1:])
+	case "del":
+		return kvDelete(args[1:])
+	case "purge":
+		return kvPurge(args[1:])
+	case "keys":
+		return kvKeys(args[1:])
+	case "history":
+		return kvHistory(args[1:])
+	case "watch":
+		return kvWatch(args[1:])
+	case "status":
+		return kvStatus(args[1:])
+	default:
+		return fmt.Errorf("unknown kv subcommand %q", args[0])
+	}
+}
+
+// Function to open a key-value bucket on a fresh connection
+func openKeyValue(server, bucket string) (*nats.Conn, nats.KeyValue, error) {
+	nc, js, err := connectJetStream(server)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kv, err := js.KeyValue(bucket)
+	if err != nil {
+		nc.Close() // Do not leak the connection if the bucket is missing
+		return nil, nil, fmt.Errorf("open bucket %q: %w", bucket, err)
+	}
+	return nc, kv, nil
+}
+
+// Function to take a value from the arguments, or from standard input when it is missing or "-"
+func readValue(args []string) ([]byte, error) {
+	if len(args) > 0 && args[0] != "-" {
+		return []byte(args[0]), nil
+	}
+	return io.ReadAll(os.Stdin)
+}
+
+// Function to print the latest value of a key
+func kvGet(args []string) error {
+	fs, server := newFlagSet("kv get")
+	asJSON := fs.Bool("json", false, "print JSON instead of text")
+	revision := fs.Uint64("revision", 0, "get this revision instead of the latest")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: kv get [-server url] [-json] [-revision n] <bucket> <key>")
+	}
+
+	nc, kv, err := openKeyValue(*server, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer nc.Close() // Close the connection when the command completes
+
+	var entry nats.KeyValueEntry
+	if *revision > 0 {
+		entry, err = kv.GetRevision(fs.Arg(1), *revision)
+	} else {
+		entry, err = kv.Get(fs.Arg(1))
+	}
+	if err != nil {
+		return fmt.Errorf("get %q: %w", fs.Arg(1), err)
+	}
+	return printEntry(entry, *asJSON)
+}
+
+// Function to write a value regardless of the current revision
+func kvPut(args []string) error {
+	fs, server := newFlagSet("kv put")
+	asJSON := fs.Bool("json", false, "print JSON instead of text")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 2 || fs.NArg() > 3 {
+		return fmt.Errorf("usage: kv put [-server url] [-json] <bucket> <key> [value|-]")
+	}
+
+	value, err := readValue(fs.Args()[2:])
+	if err != nil {
+		return err
+	}
+
+	nc, kv, err := openKeyValue(*server, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer nc.Close() // Close the connection when the command completes
+
+	revision, err := kv.Put(fs.Arg(1), value)
+	if err != nil {
+		return fmt.Errorf("put %q: %w", fs.Arg(1), err)
+	}
+	return printRevision(fs.Arg(1), revision, *asJSON)
+}
+
+// Function to write a value only if the key does not exist
+func kvCreate(args []string) error {
+	fs, server := newFlagSet("kv create")
+	asJSON := fs.Bool("json", false, "print JSON instead of text")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 2 || fs.NArg() > 3 {
+		return fmt.Errorf("usage: kv create [-server url] [-json] <bucket> <key> [value|-]")
+	}
+
+	value, err := readValue(fs.Args()[2:])
+	if err != nil {
+		return err
+	}
+
+	nc, kv, err := openKeyValue(*server, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer nc.Close() // Close the connection when the command completes
+
+	revision, err := nats_basic.CreateKey(kv, fs.Arg(1), value)
+	if err != nil {
+		return err
+	}
+	return printRevision(fs.Arg(1), revision, *asJSON)
+}
+
+// Function to write a value only if the latest revision matches the given one
+func kvUpdate(args []string) error {
+	fs, server := newFlagSet("kv update")
+	asJSON := fs.Bool("json", false, "print JSON instead of text")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 3 || fs.NArg() > 4 {
+		return fmt.Errorf("usage: kv update [-server url] [-json] <bucket> <key> <revision> [value|-]")
+	}
+
+	expected, err := strconv.ParseUint(fs.Arg(2), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid revision %q: %w", fs.Arg(2), err)
+	}
+	value, err := readValue(fs.Args()[3:])
+	if err != nil {
+		return err
+	}
+
+	nc, kv, err := openKeyValue(*server, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer nc.Close() // Close the connection when the command completes
+
+	revision, err := nats_basic.UpdateKey(kv, fs.Arg(1), value, expected)
+	if err != nil {
+		return err
+	}
+	return printRevision(fs.Arg(1), revision, *asJSON)
+}
+
+// Function to place a delete marker on a key, keeping its history
+func kvDelete(args []string) error {
+	fs, server := newFlagSet("kv del")
+	revision := fs.Uint64("revision", 0, "only delete if the latest revision matches")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: kv del [-server url] [-revision n] <bucket> <key>")
+	}
+
+	nc, kv, err := openKeyValue(*server, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer nc.Close() // Close the connection when the command completes
+
+	var opts []nats.DeleteOpt
+	if *revision > 0 {
+		opts = append(opts, nats.LastRevision(*revision))
+	}
+	if err := kv.Delete(fs.Arg(1), opts...); err != nil {
+		return fmt.Errorf("delete %q: %w", fs.Arg(1), err)
+	}
+	return nil
+}
+
+// Function to remove every revision of a key
+func kvPurge(args []string) error {
+	fs, server := newFlagSet("kv purge")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: kv purge [-server url] <bucket> <key>")
+	}
+
+	nc, kv, err := openKeyValue(*server, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer nc.Close() // Close the connection when the command completes
+
+	return nats_basic.PurgeKey(kv, fs.Arg(1))
+}
+
+// Function to list the keys of a bucket, optionally matching a pattern
+func kvKeys(args []string) error {
+	fs, server := newFlagSet("kv keys")
+	asJSON := fs.Bool("json", false, "print JSON instead of text")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		return fmt.Errorf("usage: kv keys [-server url] [-json] <bucket> [pattern]")
+	}
+
+	pattern := ">"
+	if fs.NArg() == 2 {
+		pattern = fs.Arg(1)
+	}
+
+	nc, kv, err := openKeyValue(*server, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer nc.Close() // Close the connection when the command completes
+
+	keys, err := nats_basic.ListKeys(kv, pattern)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		if keys == nil {
+			keys = []string{} // Print an empty array rather than null
+		}
+		return printJSON(keys)
+	}
+	for _, key := range keys {
+		fmt.Println(key)
+	}
+	return nil
+}
+
+// Function to print every revision of a key, including delete and purge markers
+func kvHistory(args []string) error {
+	fs, server := newFlagSet("kv history")
+	asJSON := fs.Bool("json", false, "print JSON instead of text")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: kv history [-server url] [-json] <bucket> <key>")
+	}
+
+	nc, kv, err := openKeyValue(*server, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer nc.Close() // Close the connection when the command completes
+
+	entries, err := nats_basic.KeyHistory(kv, fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := printEntry(entry, *asJSON); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Function to print changes to the keys matching a pattern until interrupted
+func kvWatch(args []string) error {
+	fs, server := newFlagSet("kv watch")
+	asJSON := fs.Bool("json", false, "print JSON instead of text")
+	history := fs.Bool("history", false, "start with every stored revision instead of the latest values")
+	updatesOnly := fs.Bool("updates-only", false, "only print changes made after the watch started")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		return fmt.Errorf("usage: kv watch [-server url] [-json] [-history|-updates-only] <bucket> [pattern]")
+	}
+
+	pattern := ">"
+	if fs.NArg() == 2 {
+		pattern = fs.Arg(1)
+	}
+
+	var opts []nats.WatchOpt
+	if *history {
+		opts = append(opts, nats.IncludeHistory())
+	}
+	if *updatesOnly {
+		opts = append(opts, nats.UpdatesOnly())
+	}
+
+	nc, kv, err := openKeyValue(*server, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer nc.Close() // Close the connection when the command completes
+
+	watcher, err := kv.Watch(pattern, opts...)
+	if err != nil {
+		return fmt.Errorf("watch %q: %w", pattern, err)
+	}
+	defer watcher.Stop() // Stop the watcher when the command completes
+
+	// Stop on Ctrl-C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case entry, ok := <-watcher.Updates():
+			if !ok {
+				return nil
+			}
+			if entry == nil {
+				continue // End of the initial values
+			}
+			if err := printEntry(entry, *asJSON); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Function to print the status of a bucket
+func kvStatus(args []string) error {
+	fs, server := newFlagSet("kv status")
+	asJSON := fs.Bool("json", false, "print JSON instead of text")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: kv status [-server url] [-json] <bucket>")
+	}
+
+	nc, kv, err := openKeyValue(*server, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer nc.Close() // Close the connection when the command completes
+
+	report, err := nats_basic.KVStatus(kv)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(report)
+	}
+	printKVStatus(report)
+	return nil
+}
+
+// Function to print a status report of a key-value bucket
+func printKVStatus(report nats_basic.KVStatusReport) {
+	fmt.Printf("Bucket: %s\n", report.Bucket)
+	fmt.Printf("  Values: %d\n", report.Values)
+	fmt.Printf("  Bytes: %d\n", report.Bytes)
+	fmt.Printf("  History: %d\n", report.History)
+	fmt.Printf("  TTL: %s\n", report.TTL)
+	fmt.Printf("  Backing store: %s\n", report.BackingStore)
+	fmt.Printf("  Stream: %s\n", report.Stream)
+	fmt.Printf("  Compressed: %t\n", report.Compressed)
+}
@@ -0,0 +1,330 @@
+package cli
+
+import (
+	"fmt"            // Import the package for formatted input/output
+	"os"             // Import the package for writing to standard output
+	"path/filepath"  // Import the package for working with file paths
+	"text/tabwriter" // Import the package for aligned table output
+	"time"           // Import the package for working with time
+
+	"github.com/nats-io/nats.go" // Import the package for working with NATS
+
+	"nats_practice/nats_basic" // Import the package with the object store helpers
+)
+
+// Function to dispatch the objstore subcommands
+func objstoreCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: objstore <put|get|ls|rm|info|bucket> [arguments]")
+	}
+
+	switch args[0] {
+	case "put":
+		return objstorePut(args[1:])
+	case "get":
+		return objstoreGet(args[1:])
+	case "ls":
+		return objstoreList(args[1:])
+	case "rm":
+		return objstoreRemove(args[1:])
+	case "info":
+		return objstoreInfo(args[1:])
+	case "bucket":
+		return objstoreBucket(args[1:])
+	default:
+		return fmt.Errorf("unknown objstore subcommand %q", args[0])
+	}
+}
+
+// Function to open an object store bucket on a fresh connection
+func openObjectStore(server, bucket string) (*nats.Conn, nats.ObjectStore, error) {
+	nc, js, err := connectJetStream(server)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	obs, err := js.ObjectStore(bucket)
+	if err != nil {
+		nc.Close() // Do not leak the connection if the bucket is missing
+		return nil, nil, fmt.Errorf("open object bucket %q: %w", bucket, err)
+	}
+	return nc, obs, nil
+}
+
+// Function to print transfer progress on standard error
+func printProgress(done, total int64) {
+	if total < 0 {
+		fmt.Fprintf(os.Stderr, "\r%d bytes", done)
+	} else {
+		fmt.Fprintf(os.Stderr, "\r%d/%d bytes", done, total)
+	}
+	if done == total {
+		fmt.Fprintln(os.Stderr)
+	}
+}
+
+// Function to upload a file into a bucket
+func objstorePut(args []string) error {
+	fs, server := newFlagSet("objstore put")
+	name := fs.String("name", "", "object name (defaults to the file name)")
+	chunkSize := fs.Uint("chunk-size", nats_basic.DefaultObjectChunkSize, "chunk size in bytes")
+	attempts := fs.Int("attempts", 3, "number of attempts")
+	progress := fs.Bool("progress", false, "print transfer progress")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: objstore put [-server url] [-name name] [-chunk-size n] [-attempts n] [-progress] <bucket> <file>")
+	}
+	if *chunkSize == 0 {
+		return fmt.Errorf("chunk size must be positive")
+	}
+
+	objectName := *name
+	if objectName == "" {
+		objectName = filepath.Base(fs.Arg(1))
+	}
+
+	nc, obs, err := openObjectStore(*server, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer nc.Close() // Close the connection when the command completes
+
+	// Every chunk is a message, so it must fit the largest payload the server accepts
+	if maxPayload := nc.MaxPayload(); uint64(*chunkSize) > uint64(maxPayload) {
+		return fmt.Errorf("chunk size %d is larger than the maximum payload of %d bytes", *chunkSize, maxPayload)
+	}
+
+	opts := nats_basic.ObjectTransferOptions{ChunkSize: uint32(*chunkSize), Attempts: *attempts, RetryWait: time.Second}
+	if *progress {
+		opts.Progress = printProgress
+	}
+
+	info, err := nats_basic.PutObjectFile(obs, objectName, fs.Arg(1), opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Stored %s: %d bytes in %d chunks, digest %s\n", info.Name, info.Size, info.Chunks, info.Digest)
+	return nil
+}
+
+// Function to download an object into a file or standard output
+func objstoreGet(args []string) error {
+	fs, server := newFlagSet("objstore get")
+	output := fs.String("o", "", "output file, - for standard output (defaults to the object name)")
+	attempts := fs.Int("attempts", 3, "number of attempts")
+	progress := fs.Bool("progress", false, "print transfer progress")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: objstore get [-server url] [-o file] [-attempts n] [-progress] <bucket> <name>")
+	}
+
+	nc, obs, err := openObjectStore(*server, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer nc.Close() // Close the connection when the command completes
+
+	// Downloads read the chunks from the backing stream, so they can resume at any chunk
+	js, err := nc.JetStream()
+	if err != nil {
+		return fmt.Errorf("create JetStream context: %w", err)
+	}
+
+	opts := nats_basic.ObjectTransferOptions{Attempts: *attempts, RetryWait: time.Second}
+	if *progress {
+		opts.Progress = printProgress
+	}
+
+	// Stream to standard output, or to a file that can resume after an interruption
+	switch path := *output; path {
+	case "-":
+		_, err = nats_basic.GetObjectStream(js, obs, fs.Arg(1), os.Stdout, opts)
+	case "":
+		_, err = nats_basic.GetObjectFile(js, obs, fs.Arg(1), filepath.Base(fs.Arg(1)), opts)
+	default:
+		_, err = nats_basic.GetObjectFile(js, obs, fs.Arg(1), path, opts)
+	}
+	return err
+}
+
+// Function to list the objects in a bucket
+func objstoreList(args []string) error {
+	fs, server := newFlagSet("objstore ls")
+	asJSON := fs.Bool("json", false, "print JSON instead of a table")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: objstore ls [-server url] [-json] <bucket>")
+	}
+
+	nc, obs, err := openObjectStore(*server, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer nc.Close() // Close the connection when the command completes
+
+	objects, err := nats_basic.ListObjects(obs)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(objects)
+	}
+
+	// Print an aligned table of the objects
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSIZE\tMODIFIED\tDIGEST")
+	for _, object := range objects {
+		digest := object.Digest
+		if object.Link != "" {
+			digest = "-> " + object.Link
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", object.Name, object.Size, object.Modified.Format(time.RFC3339), digest)
+	}
+	return tw.Flush()
+}
+
+// Function to delete an object from a bucket
+func objstoreRemove(args []string) error {
+	fs, server := newFlagSet("objstore rm")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: objstore rm [-server url] <bucket> <name>")
+	}
+
+	nc, obs, err := openObjectStore(*server, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer nc.Close() // Close the connection when the command completes
+
+	if err := obs.Delete(fs.Arg(1)); err != nil {
+		return fmt.Errorf("delete object %q: %w", fs.Arg(1), err)
+	}
+	return nil
+}
+
+// Function to print the information about an object
+func objstoreInfo(args []string) error {
+	fs, server := newFlagSet("objstore info")
+	asJSON := fs.Bool("json", false, "print JSON instead of text")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: objstore info [-server url] [-json] <bucket> <name>")
+	}
+
+	nc, obs, err := openObjectStore(*server, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer nc.Close() // Close the connection when the command completes
+
+	info, err := obs.GetInfo(fs.Arg(1))
+	if err != nil {
+		return fmt.Errorf("get object info %q: %w", fs.Arg(1), err)
+	}
+
+	summary := nats_basic.SummarizeObject(info)
+	if *asJSON {
+		return printJSON(summary)
+	}
+
+	fmt.Printf("Name: %s\n", summary.Name)
+	fmt.Printf("  Size: %d\n", summary.Size)
+	fmt.Printf("  Chunks: %d\n", summary.Chunks)
+	fmt.Printf("  Modified: %s\n", summary.Modified.Format(time.RFC3339))
+	fmt.Printf("  Digest: %s\n", summary.Digest)
+	fmt.Printf("  Description: %s\n", summary.Description)
+	for key, value := range summary.Headers {
+		fmt.Printf("  Header %s: %s\n", key, value)
+	}
+	if summary.Link != "" {
+		fmt.Printf("  Link: %s\n", summary.Link)
+	}
+	return nil
+}
+
+// Function to create or delete object store buckets
+func objstoreBucket(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: objstore bucket <create|delete> [arguments]")
+	}
+
+	switch args[0] {
+	case "create":
+		return objstoreBucketCreate(args[1:])
+	case "delete":
+		return objstoreBucketDelete(args[1:])
+	default:
+		return fmt.Errorf("unknown objstore bucket subcommand %q", args[0])
+	}
+}
+
+// Function to create an object store bucket
+func objstoreBucketCreate(args []string) error {
+	fs, server := newFlagSet("objstore bucket create")
+	description := fs.String("description", "", "bucket description")
+	ttl := fs.Duration("ttl", 0, "how long objects are kept, 0 keeps them forever")
+	maxBytes := fs.Int64("max-bytes", 0, "largest total size of the bucket, 0 means unlimited")
+	memory := fs.Bool("memory", false, "use memory storage instead of file storage")
+	replicas := fs.Int("replicas", 1, "number of replicas")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: objstore bucket create [-server url] [-description text] [-ttl d] [-max-bytes n] [-memory] [-replicas n] <bucket>")
+	}
+
+	nc, js, err := connectJetStream(*server)
+	if err != nil {
+		return err
+	}
+	defer nc.Close() // Close the connection when the command completes
+
+	storage := nats.FileStorage
+	if *memory {
+		storage = nats.MemoryStorage
+	}
+
+	_, err = nats_basic.CreateObjectBucket(js, nats_basic.ObjectBucketOptions{
+		Bucket:      fs.Arg(0),
+		Description: *description,
+		TTL:         *ttl,
+		MaxBytes:    *maxBytes,
+		Storage:     storage,
+		Replicas:    *replicas,
+	})
+	return err
+}
+
+// Function to delete an object store bucket with all its objects
+func objstoreBucketDelete(args []string) error {
+	fs, server := newFlagSet("objstore bucket delete")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: objstore bucket delete [-server url] <bucket>")
+	}
+
+	nc, js, err := connectJetStream(*server)
+	if err != nil {
+		return err
+	}
+	defer nc.Close() // Close the connection when the command completes
+
+	if err := js.DeleteObjectStore(fs.Arg(0)); err != nil {
+		return fmt.Errorf("delete object bucket %q: %w", fs.Arg(0), err)
+	}
+	return nil
+}
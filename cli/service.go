@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"fmt"            // Import the package for formatted input/output
+	"os"             // Import the package for writing to standard output
+	"text/tabwriter" // Import the package for aligned table output
+	"time"           // Import the package for working with time
+
+	"github.com/nats-io/nats.go" // Import the package for working with NATS
+
+	"nats_practice/nats_basic" // Import the package with the service helpers
+)
+
+// Function to dispatch the service subcommands
+func serviceCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: service <ls|stats|ping> [arguments]")
+	}
+
+	switch args[0] {
+	case "ls":
+		return serviceList(args[1:])
+	case "stats":
+		return serviceStats(args[1:])
+	case "ping":
+		return servicePing(args[1:])
+	default:
+		return fmt.Errorf("unknown service subcommand %q", args[0])
+	}
+}
+
+// Function to parse the common arguments of the service subcommands and connect
+func serviceArgs(name string, args []string) (nc *nats.Conn, service string, timeout time.Duration, asJSON bool, err error) {
+	fs, server := newFlagSet(name)
+	jsonFlag := fs.Bool("json", false, "print JSON instead of a table")
+	timeoutFlag := fs.Duration("timeout", time.Second, "how long to wait for replies")
+	if err := fs.Parse(args); err != nil {
+		return nil, "", 0, false, err
+	}
+	if fs.NArg() > 1 {
+		return nil, "", 0, false, fmt.Errorf("usage: %s [-server url] [-json] [-timeout d] [service]", name)
+	}
+
+	nc, err = nats.Connect(*server)
+	if err != nil {
+		return nil, "", 0, false, fmt.Errorf("connect to %s: %w", *server, err)
+	}
+	return nc, fs.Arg(0), *timeoutFlag, *jsonFlag, nil
+}
+
+// Function to list the discovered service instances and their endpoints
+func serviceList(args []string) error {
+	nc, name, timeout, asJSON, err := serviceArgs("service ls", args)
+	if err != nil {
+		return err
+	}
+	defer nc.Close() // Close the connection when the command completes
+
+	infos, err := nats_basic.DiscoverServices(nc, name, timeout)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return printJSON(infos)
+	}
+
+	// Print one row per endpoint of every instance
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SERVICE\tVERSION\tID\tENDPOINT\tSUBJECT\tQUEUE GROUP")
+	for _, info := range infos {
+		if len(info.Endpoints) == 0 {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t\t\t\n", info.Name, info.Version, info.ID)
+		}
+		for _, endpoint := range info.Endpoints {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", info.Name, info.Version, info.ID, endpoint.Name, endpoint.Subject, endpoint.QueueGroup)
+		}
+	}
+	return tw.Flush()
+}
+
+// Function to print the endpoint statistics of the discovered service instances
+func serviceStats(args []string) error {
+	nc, name, timeout, asJSON, err := serviceArgs("service stats", args)
+	if err != nil {
+		return err
+	}
+	defer nc.Close() // Close the connection when the command completes
+
+	stats, err := nats_basic.DiscoverServiceStats(nc, name, timeout)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return printJSON(stats)
+	}
+
+	// Print one row per endpoint of every instance
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SERVICE\tID\tENDPOINT\tREQUESTS\tERRORS\tAVG TIME\tLAST ERROR")
+	for _, s := range stats {
+		for _, endpoint := range s.Endpoints {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%s\t%s\n",
+				s.Name, s.ID, endpoint.Name, endpoint.NumRequests, endpoint.NumErrors, endpoint.AverageProcessingTime, endpoint.LastError)
+		}
+	}
+	return tw.Flush()
+}
+
+// Function to ping the running service instances
+func servicePing(args []string) error {
+	nc, name, timeout, asJSON, err := serviceArgs("service ping", args)
+	if err != nil {
+		return err
+	}
+	defer nc.Close() // Close the connection when the command completes
+
+	pings, err := nats_basic.PingServices(nc, name, timeout)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return printJSON(pings)
+	}
+	for _, ping := range pings {
+		fmt.Printf("%s %s %s\n", ping.Name, ping.Version, ping.ID)
+	}
+	return nil
+}
@@ -0,0 +1,377 @@
+package cli
+
+import (
+	"fmt"            // Import the package for formatted input/output
+	"os"             // Import the package for writing to standard output
+	"strconv"        // Import the package for parsing sequences
+	"strings"        // Import the package for working with strings
+	"text/tabwriter" // Import the package for aligned table output
+	"time"           // Import the package for working with time
+
+	"nats_practice/nats_basic" // Import the package with the stream helpers
+)
+
+// Function to dispatch the stream subcommands
+func streamCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: stream <ls|info|purge|rm|get> [arguments]")
+	}
+
+	switch args[0] {
+	case "ls":
+		return streamList(args[1:])
+	case "info":
+		return streamInfo(args[1:])
+	case "purge":
+		return streamPurge(args[1:])
+	case "rm":
+		return streamRemove(args[1:])
+	case "get":
+		return streamGet(args[1:])
+	default:
+		return fmt.Errorf("unknown stream subcommand %q", args[0])
+	}
+}
+
+// Function to list all streams with their message counts and sizes
+func streamList(args []string) error {
+	fs, server := newFlagSet("stream ls")
+	asJSON := fs.Bool("json", false, "print JSON instead of a table")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("usage: stream ls [-server url] [-json]")
+	}
+
+	nc, js, err := connectJetStream(*server)
+	if err != nil {
+		return err
+	}
+	defer nc.Close() // Close the connection when the command completes
+
+	reports, err := nats_basic.ListStreams(js)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		if reports == nil {
+			reports = []nats_basic.StreamReport{} // Print an empty array rather than null
+		}
+		return printJSON(reports)
+	}
+
+	// Print an aligned table of the streams
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSUBJECTS\tMESSAGES\tBYTES\tFIRST SEQ\tLAST SEQ\tCONSUMERS")
+	for _, r := range reports {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%d\t%d\n",
+			r.Name, strings.Join(r.Subjects, ","), r.Messages, r.Bytes, r.FirstSeq, r.LastSeq, r.Consumers)
+	}
+	return tw.Flush()
+}
+
+// Function to print the configuration and state of a stream
+func streamInfo(args []string) error {
+	fs, server := newFlagSet("stream info")
+	asJSON := fs.Bool("json", false, "print JSON instead of text")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: stream info [-server url] [-json] <stream>")
+	}
+
+	nc, js, err := connectJetStream(*server)
+	if err != nil {
+		return err
+	}
+	defer nc.Close() // Close the connection when the command completes
+
+	info, err := js.StreamInfo(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("stream info %q: %w", fs.Arg(0), err)
+	}
+
+	r := nats_basic.ReportStream(info)
+	if *asJSON {
+		return printJSON(r)
+	}
+
+	fmt.Printf("Stream: %s\n", r.Name)
+	fmt.Printf("  Subjects: %s\n", strings.Join(r.Subjects, ", "))
+	fmt.Printf("  Storage: %s\n", r.Storage)
+	fmt.Printf("  Retention: %s\n", r.Retention)
+	fmt.Printf("  Created: %s\n", r.Created.Format(time.RFC3339))
+	fmt.Printf("  Messages: %d\n", r.Messages)
+	fmt.Printf("  Bytes: %d\n", r.Bytes)
+	fmt.Printf("  First sequence: %d (%s)\n", r.FirstSeq, r.FirstTime.Format(time.RFC3339))
+	fmt.Printf("  Last sequence: %d (%s)\n", r.LastSeq, r.LastTime.Format(time.RFC3339))
+	fmt.Printf("  Consumers: %d\n", r.Consumers)
+	return nil
+}
+
+// Function to remove all messages from a stream
+func streamPurge(args []string) error {
+	fs, server := newFlagSet("stream purge")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: stream purge [-server url] <stream>")
+	}
+
+	nc, js, err := connectJetStream(*server)
+	if err != nil {
+		return err
+	}
+	defer nc.Close() // Close the connection when the command completes
+
+	if err := js.PurgeStream(fs.Arg(0)); err != nil {
+		return fmt.Errorf("purge stream %q: %w", fs.Arg(0), err)
+	}
+	return nil
+}
+
+// Function to delete a stream with all its messages and consumers
+func streamRemove(args []string) error {
+	fs, server := newFlagSet("stream rm")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: stream rm [-server url] <stream>")
+	}
+
+	nc, js, err := connectJetStream(*server)
+	if err != nil {
+		return err
+	}
+	defer nc.Close() // Close the connection when the command completes
+
+	if err := js.DeleteStream(fs.Arg(0)); err != nil {
+		return fmt.Errorf("delete stream %q: %w", fs.Arg(0), err)
+	}
+	return nil
+}
+
+// Function to print a single stored message by sequence
+func streamGet(args []string) error {
+	fs, server := newFlagSet("stream get")
+	asJSON := fs.Bool("json", false, "print JSON instead of text")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: stream get [-server url] [-json] <stream> <seq>")
+	}
+
+	seq, err := strconv.ParseUint(fs.Arg(1), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid sequence %q: %w", fs.Arg(1), err)
+	}
+
+	nc, js, err := connectJetStream(*server)
+	if err != nil {
+		return err
+	}
+	defer nc.Close() // Close the connection when the command completes
+
+	msg, err := js.GetMsg(fs.Arg(0), seq)
+	if err != nil {
+		return fmt.Errorf("get message %d from %q: %w", seq, fs.Arg(0), err)
+	}
+
+	if *asJSON {
+		return printJSON(map[string]any{
+			"subject":  msg.Subject,
+			"sequence": msg.Sequence,
+			"time":     msg.Time,
+			"headers":  msg.Header,
+			"data":     string(msg.Data),
+		})
+	}
+
+	fmt.Printf("Subject: %s\n", msg.Subject)
+	fmt.Printf("Sequence: %d\n", msg.Sequence)
+	fmt.Printf("Time: %s\n", msg.Time.Format(time.RFC3339Nano))
+	for key, values := range msg.Header {
+		fmt.Printf("Header %s: %s\n", key, strings.Join(values, ", "))
+	}
+	fmt.Printf("Data: %s\n", string(msg.Data))
+	return nil
+}
+
+// Function to dispatch the consumer subcommands
+func consumerCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: consumer <ls|info|rm|next> [arguments]")
+	}
+
+	switch args[0] {
+	case "ls":
+		return consumerList(args[1:])
+	case "info":
+		return consumerInfo(args[1:])
+	case "rm":
+		return consumerRemove(args[1:])
+	case "next":
+		return consumerNext(args[1:])
+	default:
+		return fmt.Errorf("unknown consumer subcommand %q", args[0])
+	}
+}
+
+// Function to list the consumers of a stream with their pending counts
+func consumerList(args []string) error {
+	fs, server := newFlagSet("consumer ls")
+	asJSON := fs.Bool("json", false, "print JSON instead of a table")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: consumer ls [-server url] [-json] <stream>")
+	}
+
+	nc, js, err := connectJetStream(*server)
+	if err != nil {
+		return err
+	}
+	defer nc.Close() // Close the connection when the command completes
+
+	reports, err := nats_basic.ListConsumers(js, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		if reports == nil {
+			reports = []nats_basic.ConsumerReport{} // Print an empty array rather than null
+		}
+		return printJSON(reports)
+	}
+
+	// Print an aligned table of the consumers
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tFILTER\tPENDING\tACK PENDING\tREDELIVERED\tDELIVERED SEQ\tACK FLOOR")
+	for _, r := range reports {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%d\t%d\n",
+			r.Name, r.FilterSubject, r.NumPending, r.NumAckPending, r.NumRedelivered, r.DeliveredSeq, r.AckFloorSeq)
+	}
+	return tw.Flush()
+}
+
+// Function to print the configuration and progress of a consumer
+func consumerInfo(args []string) error {
+	fs, server := newFlagSet("consumer info")
+	asJSON := fs.Bool("json", false, "print JSON instead of text")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: consumer info [-server url] [-json] <stream> <consumer>")
+	}
+
+	nc, js, err := connectJetStream(*server)
+	if err != nil {
+		return err
+	}
+	defer nc.Close() // Close the connection when the command completes
+
+	info, err := js.ConsumerInfo(fs.Arg(0), fs.Arg(1))
+	if err != nil {
+		return fmt.Errorf("consumer info %s/%s: %w", fs.Arg(0), fs.Arg(1), err)
+	}
+
+	r := nats_basic.ReportConsumer(info)
+	if *asJSON {
+		return printJSON(r)
+	}
+
+	fmt.Printf("Consumer: %s/%s\n", r.Stream, r.Name)
+	fmt.Printf("  Filter subject: %s\n", r.FilterSubject)
+	fmt.Printf("  Ack policy: %s\n", r.AckPolicy)
+	fmt.Printf("  Ack wait: %s\n", r.AckWait)
+	fmt.Printf("  Max deliver: %d\n", r.MaxDeliver)
+	fmt.Printf("  Created: %s\n", r.Created.Format(time.RFC3339))
+	fmt.Printf("  Pending: %d\n", r.NumPending)
+	fmt.Printf("  Ack pending: %d\n", r.NumAckPending)
+	fmt.Printf("  Redelivered: %d\n", r.NumRedelivered)
+	fmt.Printf("  Waiting pulls: %d\n", r.NumWaiting)
+	fmt.Printf("  Last delivered stream sequence: %d\n", r.DeliveredSeq)
+	fmt.Printf("  Ack floor stream sequence: %d\n", r.AckFloorSeq)
+	return nil
+}
+
+// Function to delete a consumer
+func consumerRemove(args []string) error {
+	fs, server := newFlagSet("consumer rm")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: consumer rm [-server url] <stream> <consumer>")
+	}
+
+	nc, js, err := connectJetStream(*server)
+	if err != nil {
+		return err
+	}
+	defer nc.Close() // Close the connection when the command completes
+
+	if err := js.DeleteConsumer(fs.Arg(0), fs.Arg(1)); err != nil {
+		return fmt.Errorf("delete consumer %s/%s: %w", fs.Arg(0), fs.Arg(1), err)
+	}
+	return nil
+}
+
+// Function to fetch and print the next message of a pull consumer
+func consumerNext(args []string) error {
+	fs, server := newFlagSet("consumer next")
+	asJSON := fs.Bool("json", false, "print JSON instead of text")
+	ack := fs.Bool("ack", false, "acknowledge the message")
+	wait := fs.Duration("wait", 5*time.Second, "how long to wait for a message")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: consumer next [-server url] [-json] [-ack] [-wait d] <stream> <consumer>")
+	}
+
+	nc, js, err := connectJetStream(*server)
+	if err != nil {
+		return err
+	}
+	defer nc.Close() // Close the connection when the command completes
+
+	msg, err := nats_basic.FetchNext(js, fs.Arg(0), fs.Arg(1), *wait, *ack)
+	if err != nil {
+		return err
+	}
+
+	meta, err := msg.Metadata()
+	if err != nil {
+		return fmt.Errorf("read message metadata: %w", err)
+	}
+
+	if *asJSON {
+		return printJSON(map[string]any{
+			"subject":      msg.Subject,
+			"stream_seq":   meta.Sequence.Stream,
+			"consumer_seq": meta.Sequence.Consumer,
+			"delivered":    meta.NumDelivered,
+			"pending":      meta.NumPending,
+			"time":         meta.Timestamp,
+			"acked":        *ack,
+			"data":         string(msg.Data),
+		})
+	}
+
+	fmt.Printf("Subject: %s\n", msg.Subject)
+	fmt.Printf("Stream sequence: %d\n", meta.Sequence.Stream)
+	fmt.Printf("Consumer sequence: %d\n", meta.Sequence.Consumer)
+	fmt.Printf("Delivered: %d times\n", meta.NumDelivered)
+	fmt.Printf("Pending: %d\n", meta.NumPending)
+	fmt.Printf("Acknowledged: %t\n", *ack)
+	fmt.Printf("Data: %s\n", string(msg.Data))
+	return nil
+}
@@ -1,15 +1,19 @@
 package goroutines
 
 import (
-	"fmt"  // Import the package for formatted input/output
 	"sync" // Import the package for synchronizing goroutines
 )
 
-// Function to launch 10 goroutines, each printing numbers from 1 to 10
+// Function to launch 10 goroutines, each logging numbers from 1 to 10
 func LaunchGoroutines() {
-	// Print a message about launching 10 goroutines
-	fmt.Println("\n--- Launching 10 goroutines ---")
+	// Log a message about launching 10 goroutines
+	logger().Info("Launching 10 goroutines")
 
+	RunGoroutines(LogSink())
+}
+
+// Function to launch 10 goroutines, each emitting numbers from 1 to 10 to the sink
+func RunGoroutines(sink Sink) {
 	// Create a WaitGroup variable to wait for all goroutines to complete
 	var wg sync.WaitGroup
 
@@ -23,9 +27,9 @@ func LaunchGoroutines() {
 			// Decrement the WaitGroup counter by 1 after the goroutine completes
 			defer wg.Done()
 
-			// Print numbers from 1 to 10
+			// Emit numbers from 1 to 10
 			for j := 1; j <= 10; j++ {
-				fmt.Printf("Goroutine %d: %d\n", id, j)
+				sink.Emit(Event{Msg: "Goroutine step", Source: id, Value: j})
 			}
 		}(i)
 	}
@@ -34,11 +38,16 @@ func LaunchGoroutines() {
 	wg.Wait()
 }
 
-// Function to send data to a channel and print it from another goroutine
+// Function to send data to a channel and log it from another goroutine
 func ChannelExample() {
-	// Print a message about launching the channel example
-	fmt.Println("\n--- Sending data to a channel and receiving data from a channel ---")
+	// Log a message about launching the channel example
+	logger().Info("Sending data to a channel and receiving data from a channel")
+
+	RunChannel(LogSink())
+}
 
+// Function to send data to a channel and emit it to the sink from another goroutine
+func RunChannel(sink Sink) {
 	// Create a channel for integers
 	ch := make(chan int)
 
@@ -55,12 +64,12 @@ func ChannelExample() {
 		close(ch) // Close the channel after sending all data
 	}()
 
-	// Launch a goroutine to receive data from the channel and print it
+	// Launch a goroutine to receive data from the channel and emit it
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 		for val := range ch {
-			fmt.Println("Received:", val) // Receive data from the channel until it is closed
+			sink.Emit(Event{Msg: "Received", Source: 1, Value: val}) // Receive data from the channel until it is closed
 		}
 	}()
 
@@ -70,9 +79,14 @@ func ChannelExample() {
 
 // Function to demonstrate buffered channels
 func BufferedChannelExample() {
-	// Print a message about launching the buffered channels example
-	fmt.Println("\n--- Example of using buffered channels ---")
+	// Log a message about launching the buffered channels example
+	logger().Info("Example of using buffered channels")
 
+	RunBufferedChannel(LogSink())
+}
+
+// Function to send data through a buffered channel, emitting every send and receive to the sink
+func RunBufferedChannel(sink Sink) {
 	// Create a buffered channel with a capacity of 5
 	ch := make(chan int, 5)
 
@@ -84,19 +98,19 @@ func BufferedChannelExample() {
 	go func() {
 		defer wg.Done()
 		for i := 1; i <= 10; i++ {
-			fmt.Println("Sending:", i)
+			sink.Emit(Event{Msg: "Sending", Source: 1, Value: i})
 			ch <- i // Send data to the buffered channel
-			fmt.Println("Sent:", i)
+			sink.Emit(Event{Msg: "Sent", Source: 1, Value: i})
 		}
 		close(ch) // Close the channel after sending all data
 	}()
 
-	// Launch a goroutine to receive data from the buffered channel and print it
+	// Launch a goroutine to receive data from the buffered channel and emit it
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 		for val := range ch {
-			fmt.Println("Received from buffered channel:", val)
+			sink.Emit(Event{Msg: "Received from buffered channel", Source: 1, Value: val})
 		}
 	}()
 
@@ -106,9 +120,15 @@ func BufferedChannelExample() {
 
 // Function to demonstrate the select statement
 func SelectExample() {
-	// Print a message about launching the select statement example
-	fmt.Println("\n--- Example of using the select statement ---")
+	// Log a message about launching the select statement example
+	logger().Info("Example of using the select statement")
+
+	RunSelect(LogSink())
+}
 
+// Function to receive from two channels with the select statement, emitting the values to the sink
+// with the channel number as source
+func RunSelect(sink Sink) {
 	// Create two channels
 	ch1 := make(chan int)
 	ch2 := make(chan int)
@@ -144,13 +164,13 @@ func SelectExample() {
 			select {
 			case val, ok := <-ch1:
 				if ok {
-					fmt.Println("Received from ch1:", val) // Process data from the first channel
+					sink.Emit(Event{Msg: "Received from channel", Source: 1, Value: val}) // Process data from the first channel
 				} else {
 					ch1 = nil // Set the channel to nil to avoid further reads
 				}
 			case val, ok := <-ch2:
 				if ok {
-					fmt.Println("Received from ch2:", val) // Process data from the second channel
+					sink.Emit(Event{Msg: "Received from channel", Source: 2, Value: val}) // Process data from the second channel
 				} else {
 					ch2 = nil // Set the channel to nil to avoid further reads
 				}
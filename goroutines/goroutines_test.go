@@ -0,0 +1,149 @@
+package goroutines
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Function to run an example through a new collector and return the collected events
+func collect(run func(sink Sink)) []Event {
+	c := NewCollector()
+	run(c)
+	return c.Events()
+}
+
+func TestRunGoroutinesEmitsEveryStep(t *testing.T) {
+	events := collect(RunGoroutines)
+	if len(events) != 100 {
+		t.Fatalf("got %d events, want 100", len(events))
+	}
+
+	seen := map[[2]int]bool{}
+	for _, e := range events {
+		key := [2]int{e.Source, e.Value}
+		if seen[key] {
+			t.Errorf("duplicate event %v", e)
+		}
+		seen[key] = true
+	}
+	for source := 1; source <= 10; source++ {
+		for value := 1; value <= 10; value++ {
+			if !seen[[2]int{source, value}] {
+				t.Errorf("missing event source=%d value=%d", source, value)
+			}
+		}
+	}
+}
+
+func TestRunChannelReceivesInOrder(t *testing.T) {
+	// A single receiver emits every event, in the order the values were sent
+	var received []int
+	RunChannel(SinkFunc(func(e Event) {
+		received = append(received, e.Value)
+	}))
+	if want := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}; fmt.Sprint(received) != fmt.Sprint(want) {
+		t.Errorf("received %v, want %v", received, want)
+	}
+
+	events := collect(RunChannel)
+	if len(events) != 10 {
+		t.Fatalf("got %d events, want 10", len(events))
+	}
+	for i, e := range events {
+		if e.Msg != "Received" || e.Source != 1 || e.Value != i+1 {
+			t.Errorf("event %d is %v", i, e)
+		}
+	}
+}
+
+func TestRunBufferedChannelEmitsEverySendAndReceive(t *testing.T) {
+	events := collect(RunBufferedChannel)
+	if len(events) != 30 {
+		t.Fatalf("got %d events, want 30", len(events))
+	}
+
+	counts := map[string]int{}
+	for _, e := range events {
+		counts[fmt.Sprintf("%s %d", e.Msg, e.Value)]++
+	}
+	for value := 1; value <= 10; value++ {
+		for _, msg := range []string{"Sending", "Sent", "Received from buffered channel"} {
+			if n := counts[fmt.Sprintf("%s %d", msg, value)]; n != 1 {
+				t.Errorf("%q for value %d emitted %d times, want once", msg, value, n)
+			}
+		}
+	}
+}
+
+func TestRunSelectReceivesFromBothChannels(t *testing.T) {
+	events := collect(RunSelect)
+	if len(events) != 10 {
+		t.Fatalf("got %d events, want 10", len(events))
+	}
+	for i, e := range events {
+		// Values 1 to 5 come from the first channel, 6 to 10 from the second
+		wantSource := 1
+		if i >= 5 {
+			wantSource = 2
+		}
+		if e.Msg != "Received from channel" || e.Source != wantSource || e.Value != i+1 {
+			t.Errorf("event %d is %v, want source %d and value %d", i, e, wantSource, i+1)
+		}
+	}
+}
+
+func TestCollectorOutputIsStable(t *testing.T) {
+	examples := []struct {
+		name string
+		run  func(sink Sink)
+	}{
+		{"goroutines", RunGoroutines},
+		{"channel", RunChannel},
+		{"buffered channel", RunBufferedChannel},
+		{"select", RunSelect},
+	}
+	for _, ex := range examples {
+		t.Run(ex.name, func(t *testing.T) {
+			var first string
+			for run := 0; run < 20; run++ {
+				c := NewCollector()
+				ex.run(c)
+
+				var buf bytes.Buffer
+				n, err := c.WriteTo(&buf)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if n != int64(buf.Len()) {
+					t.Errorf("WriteTo reported %d bytes, wrote %d", n, buf.Len())
+				}
+
+				if run == 0 {
+					first = buf.String()
+					continue
+				}
+				if buf.String() != first {
+					t.Fatalf("run %d wrote\n%s\nwant\n%s", run, buf.String(), first)
+				}
+			}
+		})
+	}
+}
+
+func TestCollectorWriteToFormat(t *testing.T) {
+	c := NewCollector()
+	c.Emit(Event{Msg: "b", Source: 2, Value: 1})
+	c.Emit(Event{Msg: "a", Source: 1, Value: 2})
+	c.Emit(Event{Msg: "a", Source: 1, Value: 1})
+
+	var buf strings.Builder
+	if _, err := c.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "a source=1 value=1\na source=1 value=2\nb source=2 value=1\n"
+	if buf.String() != want {
+		t.Errorf("WriteTo wrote %q, want %q", buf.String(), want)
+	}
+}
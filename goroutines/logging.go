@@ -0,0 +1,22 @@
+package goroutines
+
+import (
+	"log/slog"    // Import the package for structured logging
+	"sync/atomic" // Import the package for swapping the logger safely
+)
+
+// Logger set with SetLogger, nil uses slog.Default()
+var currentLogger atomic.Pointer[slog.Logger]
+
+// Function to set the logger used by the package; nil restores slog.Default()
+func SetLogger(l *slog.Logger) {
+	currentLogger.Store(l)
+}
+
+// Function to get the logger used by the package
+func logger() *slog.Logger {
+	if l := currentLogger.Load(); l != nil {
+		return l
+	}
+	return slog.Default()
+}
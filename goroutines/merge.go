@@ -0,0 +1,256 @@
+package goroutines
+
+import (
+	"context" // Import the package for cancelling the merge
+	"errors"  // Import the package for working with errors
+	"reflect" // Import the package for selecting over any number of channels
+	"sync"    // Import the package for protecting the merger state
+	"time"    // Import the package for ordering values by timestamp
+)
+
+// Error returned when adding a channel to a merger that was closed or cancelled
+var ErrMergerClosed = errors.New("merger is closed")
+
+// Value received from a merged channel together with the channel it came from
+type Sourced[T any] struct {
+	Source int // Index of the source channel, in the order the channels were added
+	Value  T   // Received value
+}
+
+// Source channel waiting to be added to the select loop
+type mergeSource[T any] struct {
+	id int      // Index of the source channel
+	ch <-chan T // Source channel
+}
+
+// Merge of any number of channels into one, accepting new channels while it runs
+type Merger[T any] struct {
+	ctx  context.Context // Stops the merge when cancelled
+	out  chan Sourced[T] // Merged values
+	wake chan struct{}   // Signals the select loop that channels were added or Close was called
+	done chan struct{}   // Closed once the select loop returned
+
+	mu      sync.Mutex       // Protects the fields below
+	closed  bool             // Whether Close was called
+	next    int              // Index of the next added channel
+	pending []mergeSource[T] // Added channels not yet taken by the select loop
+}
+
+// Function to start merging the given channels
+func NewMerger[T any](ctx context.Context, ins ...<-chan T) *Merger[T] {
+	m := &Merger[T]{
+		ctx:  ctx,
+		out:  make(chan Sourced[T]),
+		wake: make(chan struct{}, 1),
+		done: make(chan struct{}),
+	}
+	for _, in := range ins {
+		m.pending = append(m.pending, mergeSource[T]{id: m.next, ch: in})
+		m.next++
+	}
+	m.signal()
+
+	go m.run()
+	return m
+}
+
+// Function to merge channels until all of them are closed or the context is cancelled
+func Merge[T any](ctx context.Context, ins ...<-chan T) <-chan Sourced[T] {
+	m := NewMerger(ctx, ins...)
+	m.Close() // No channels are added later
+	return m.Out()
+}
+
+// Function to get the merged values, closed once every channel is drained after Close
+func (m *Merger[T]) Out() <-chan Sourced[T] {
+	return m.out
+}
+
+// Function to add a channel to the merge, returning its source index. It does not wait for the
+// select loop, so it may be called before anyone reads the output.
+func (m *Merger[T]) Add(in <-chan T) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	select {
+	case <-m.done:
+		return 0, ErrMergerClosed // The context was cancelled
+	default:
+	}
+	if m.closed {
+		return 0, ErrMergerClosed
+	}
+
+	id := m.next
+	m.next++
+	m.pending = append(m.pending, mergeSource[T]{id: id, ch: in})
+	m.signal()
+	return id, nil
+}
+
+// Function to stop accepting channels, so the output is closed once the added ones are drained
+func (m *Merger[T]) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.closed = true
+	m.signal()
+}
+
+// Function to wake up the select loop without waiting for it
+func (m *Merger[T]) signal() {
+	select {
+	case m.wake <- struct{}{}:
+	default: // A wake-up is already pending
+	}
+}
+
+// Function to select over the context, the wake-up channel and every source channel
+func (m *Merger[T]) run() {
+	defer close(m.out)
+	defer close(m.done)
+
+	// The first two cases are fixed, one case per source follows
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(m.ctx.Done())},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(m.wake)},
+	}
+	ids := []int{-1, -1}
+	accepting := true // Whether channels may still be added
+
+	for accepting || len(cases) > 2 {
+		chosen, value, ok := reflect.Select(cases)
+		switch {
+		case chosen == 0:
+			return // The context was cancelled
+		case chosen == 1:
+			// Take the added channels and check whether Close was called
+			m.mu.Lock()
+			for _, s := range m.pending {
+				cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(s.ch)})
+				ids = append(ids, s.id)
+			}
+			m.pending = nil
+			accepting = !m.closed
+			m.mu.Unlock()
+		case !ok:
+			// Drop the closed source, like setting a closed channel to nil in a select statement
+			cases = append(cases[:chosen], cases[chosen+1:]...)
+			ids = append(ids[:chosen], ids[chosen+1:]...)
+		default:
+			select {
+			case m.out <- Sourced[T]{Source: ids[chosen], Value: mergeValue[T](value)}:
+			case <-m.ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// Function to convert a received value, a nil interface value becomes the zero value of T
+func mergeValue[T any](value reflect.Value) T {
+	v, _ := value.Interface().(T)
+	return v
+}
+
+// Function to merge channels whose values arrive in timestamp order into one channel in timestamp
+// order. A value is sent only once every open channel has a value waiting, so a silent channel holds
+// back the others until it sends or is closed. Values with equal timestamps keep the channel order.
+func MergeOrdered[T any](ctx context.Context, timestamp func(v T) time.Time, ins ...<-chan T) <-chan Sourced[T] {
+	out := make(chan Sourced[T])
+	go func() {
+		defer close(out)
+
+		heads := make([]*T, len(ins)) // Next value of every channel, nil when none is waiting
+		open := make([]bool, len(ins))
+		for i := range open {
+			open[i] = true
+		}
+
+		for {
+			// Wait for a value from every open channel without one
+			for i, in := range ins {
+				if !open[i] || heads[i] != nil {
+					continue
+				}
+				select {
+				case v, ok := <-in:
+					if !ok {
+						open[i] = false
+						continue
+					}
+					heads[i] = &v
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			// Send the earliest waiting value
+			earliest := -1
+			for i, head := range heads {
+				if head != nil && (earliest < 0 || timestamp(*head).Before(timestamp(*heads[earliest]))) {
+					earliest = i
+				}
+			}
+			if earliest < 0 {
+				return // Every channel is closed
+			}
+			select {
+			case out <- Sourced[T]{Source: earliest, Value: *heads[earliest]}:
+				heads[earliest] = nil
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Function to demonstrate merging channels, including one added later and a merge in timestamp order
+func MergeExample() {
+	// Log a message about launching the merge example
+	logger().Info("Example of merging channels")
+
+	// Function to send a range of numbers to a new channel
+	send := func(from, to int) <-chan int {
+		ch := make(chan int)
+		go func() {
+			defer close(ch)
+			for i := from; i <= to; i++ {
+				ch <- i
+			}
+		}()
+		return ch
+	}
+
+	// Merge three channels, adding the third one while the merge is running
+	m := NewMerger(context.Background(), send(1, 3), send(4, 6))
+	if _, err := m.Add(send(7, 10)); err != nil {
+		logger().Error("Error adding the channel", "error", err)
+	}
+	m.Close() // No more channels, the output closes when the three are drained
+
+	for v := range m.Out() {
+		logger().Info("Received from channel", "source", v.Source, "value", v.Value)
+	}
+
+	// Merge two event streams, each sorted by time, into a single stream sorted by time
+	type event struct {
+		Name string
+		At   time.Time
+	}
+	start := time.Now()
+	events := func(names ...string) <-chan event {
+		ch := make(chan event, len(names))
+		for i, name := range names {
+			ch <- event{Name: name, At: start.Add(time.Duration(len(name)*10+i) * time.Millisecond)}
+		}
+		close(ch)
+		return ch
+	}
+
+	at := func(e event) time.Time { return e.At }
+	for v := range MergeOrdered(context.Background(), at, events("a", "ccc", "eeeee"), events("bb", "dddd")) {
+		logger().Info("Received event", "source", v.Source, "name", v.Value.Name, "offset", v.Value.At.Sub(start))
+	}
+}
@@ -0,0 +1,319 @@
+package goroutines
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"testing"
+	"time"
+)
+
+// Function to create a closed channel holding the given values
+func values(vs ...int) <-chan int {
+	ch := make(chan int, len(vs))
+	for _, v := range vs {
+		ch <- v
+	}
+	close(ch)
+	return ch
+}
+
+// Function to create a channel sending numbers until the context is cancelled
+func endless(ctx context.Context) <-chan int {
+	ch := make(chan int)
+	go func() {
+		defer close(ch)
+		for i := 0; ; i++ {
+			select {
+			case ch <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// Function to format merged values sorted by source and value, as "source:value"
+func sortedSources(got []Sourced[int]) string {
+	sort.Slice(got, func(i, j int) bool {
+		if got[i].Source != got[j].Source {
+			return got[i].Source < got[j].Source
+		}
+		return got[i].Value < got[j].Value
+	})
+	var s []string
+	for _, v := range got {
+		s = append(s, fmt.Sprintf("%d:%d", v.Source, v.Value))
+	}
+	return fmt.Sprint(s)
+}
+
+func TestMergeClosesAfterEveryInput(t *testing.T) {
+	tests := []struct {
+		name string
+		ins  []<-chan int
+		want string
+	}{
+		{"no inputs", nil, "[]"},
+		{"one input", []<-chan int{values(1, 2, 3)}, "[0:1 0:2 0:3]"},
+		{"several inputs", []<-chan int{values(1, 2), values(3), values(4, 5)}, "[0:1 0:2 1:3 2:4 2:5]"},
+		{"empty inputs keep their index", []<-chan int{values(), values(1), values()}, "[1:1]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := drain(t, Merge(context.Background(), tt.ins...))
+			if s := sortedSources(got); s != tt.want {
+				t.Errorf("got %s, want %s", s, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergerAddBeforeAndWhileReading(t *testing.T) {
+	first := make(chan int, 1) // Buffered, so sending to it never waits for the merged output
+	m := NewMerger[int](context.Background(), first)
+
+	// Add does not wait for a reader
+	added := make(chan error, 1)
+	go func() {
+		_, err := m.Add(values(10, 11))
+		added <- err
+	}()
+	select {
+	case err := <-added:
+		if err != nil {
+			t.Fatalf("Add before reading failed: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Add blocked without a reader")
+	}
+
+	// Read a value of the first channel, then add one more channel while the merge runs
+	first <- 1
+	var got []Sourced[int]
+	for len(got) < 3 {
+		got = append(got, <-m.Out())
+	}
+	id, err := m.Add(values(20))
+	if err != nil {
+		t.Fatalf("Add while reading failed: %v", err)
+	}
+	if id != 2 {
+		t.Errorf("Add returned source %d, want 2", id)
+	}
+
+	close(first)
+	m.Close()
+	got = append(got, drain(t, m.Out())...)
+	if s := sortedSources(got); s != "[0:1 1:10 1:11 2:20]" {
+		t.Errorf("got %s, want [0:1 1:10 1:11 2:20]", s)
+	}
+}
+
+func TestMergerAddAfterStop(t *testing.T) {
+	tests := []struct {
+		name string
+		stop func(m *Merger[int], cancel context.CancelFunc)
+	}{
+		{"after Close", func(m *Merger[int], cancel context.CancelFunc) { m.Close() }},
+		{"after Close with the output drained", func(m *Merger[int], cancel context.CancelFunc) {
+			m.Close()
+			for range m.Out() {
+			}
+		}},
+		{"after the context is cancelled", func(m *Merger[int], cancel context.CancelFunc) {
+			cancel()
+			for range m.Out() {
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			m := NewMerger[int](ctx, values(1))
+			tt.stop(m, cancel)
+
+			if _, err := m.Add(values(2)); !errors.Is(err, ErrMergerClosed) {
+				t.Errorf("Add returned %v, want %v", err, ErrMergerClosed)
+			}
+			drain(t, m.Out())
+		})
+	}
+}
+
+func TestMergerCloseDrainsPendingSources(t *testing.T) {
+	open := make(chan int)
+	m := NewMerger[int](context.Background(), values(1, 2))
+
+	// Add channels and close before anything is read, so they may still be pending
+	for _, in := range []<-chan int{values(3), open, values(4, 5)} {
+		if _, err := m.Add(in); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m.Close()
+
+	// The output stays open while an added channel is open
+	var got []Sourced[int]
+	for len(got) < 5 {
+		got = append(got, <-m.Out())
+	}
+	select {
+	case v, ok := <-m.Out():
+		t.Fatalf("got %v and %v while a source was open", v, ok)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	open <- 6
+	close(open)
+	got = append(got, drain(t, m.Out())...)
+	if s := sortedSources(got); s != "[0:1 0:2 1:3 2:6 3:4 3:5]" {
+		t.Errorf("got %s, want [0:1 0:2 1:3 2:6 3:4 3:5]", s)
+	}
+}
+
+func TestMergeCancellationClosesOutput(t *testing.T) {
+	tests := []struct {
+		name  string
+		merge func(t *testing.T, ctx context.Context, ins ...<-chan int) <-chan Sourced[int]
+		read  int // Values read before cancelling
+	}{
+		{"Merge unread", func(t *testing.T, ctx context.Context, ins ...<-chan int) <-chan Sourced[int] {
+			return Merge(ctx, ins...)
+		}, 0},
+		{"Merge while reading", func(t *testing.T, ctx context.Context, ins ...<-chan int) <-chan Sourced[int] {
+			return Merge(ctx, ins...)
+		}, 10},
+		{"Merger with added channels", func(t *testing.T, ctx context.Context, ins ...<-chan int) <-chan Sourced[int] {
+			m := NewMerger[int](ctx)
+			for _, in := range ins {
+				if _, err := m.Add(in); err != nil {
+					t.Fatal(err)
+				}
+			}
+			return m.Out() // Never closed, only the context stops it
+		}, 10},
+		{"MergeOrdered unread", func(t *testing.T, ctx context.Context, ins ...<-chan int) <-chan Sourced[int] {
+			return MergeOrdered(ctx, func(v int) time.Time { return time.Unix(0, int64(v)) }, ins...)
+		}, 0},
+		{"MergeOrdered while reading", func(t *testing.T, ctx context.Context, ins ...<-chan int) <-chan Sourced[int] {
+			return MergeOrdered(ctx, func(v int) time.Time { return time.Unix(0, int64(v)) }, ins...)
+		}, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := runtime.NumGoroutine()
+			ctx, cancel := context.WithCancel(context.Background())
+
+			out := tt.merge(t, ctx, endless(ctx), endless(ctx), endless(ctx))
+			for i := 0; i < tt.read; i++ {
+				<-out
+			}
+			cancel()
+
+			drain(t, out)
+			waitForGoroutines(t, before)
+		})
+	}
+}
+
+// Value with a timestamp, named to tell apart values with equal timestamps
+type stamped struct {
+	At   int    // Timestamp in nanoseconds
+	Name string // Name of the value
+}
+
+// Function to create a closed channel holding the given values
+func stampedValues(vs ...stamped) <-chan stamped {
+	ch := make(chan stamped, len(vs))
+	for _, v := range vs {
+		ch <- v
+	}
+	close(ch)
+	return ch
+}
+
+func TestMergeOrdered(t *testing.T) {
+	tests := []struct {
+		name string
+		ins  []<-chan stamped
+		want string // Sent values as "source:name"
+	}{
+		{
+			name: "interleaved timestamps",
+			ins: []<-chan stamped{
+				stampedValues(stamped{1, "a"}, stamped{3, "c"}, stamped{5, "e"}),
+				stampedValues(stamped{2, "b"}, stamped{4, "d"}),
+			},
+			want: "[0:a 1:b 0:c 1:d 0:e]",
+		},
+		{
+			name: "equal timestamps keep the channel order",
+			ins: []<-chan stamped{
+				stampedValues(stamped{1, "a"}, stamped{2, "c"}),
+				stampedValues(stamped{1, "b"}, stamped{2, "d"}),
+			},
+			want: "[0:a 1:b 0:c 1:d]",
+		},
+		{
+			name: "later channel first",
+			ins: []<-chan stamped{
+				stampedValues(stamped{10, "c"}),
+				stampedValues(stamped{1, "a"}, stamped{2, "b"}),
+			},
+			want: "[1:a 1:b 0:c]",
+		},
+		{
+			name: "closed channel is skipped",
+			ins: []<-chan stamped{
+				stampedValues(),
+				stampedValues(stamped{2, "b"}),
+				stampedValues(stamped{1, "a"}),
+			},
+			want: "[2:a 1:b]",
+		},
+		{
+			name: "no inputs",
+			want: "[]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at := func(v stamped) time.Time { return time.Unix(0, int64(v.At)) }
+			var got []string
+			for _, v := range drain(t, MergeOrdered(context.Background(), at, tt.ins...)) {
+				got = append(got, fmt.Sprintf("%d:%s", v.Source, v.Value.Name))
+			}
+			if fmt.Sprint(got) != tt.want {
+				t.Errorf("got %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeOrderedWaitsForSilentChannel(t *testing.T) {
+	silent := make(chan stamped)
+	at := func(v stamped) time.Time { return time.Unix(0, int64(v.At)) }
+	out := MergeOrdered(context.Background(), at, stampedValues(stamped{5, "b"}), silent)
+
+	// The silent channel may still send an earlier value, so nothing is sent yet
+	select {
+	case v := <-out:
+		t.Fatalf("got %v while a channel had no value waiting", v)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	silent <- stamped{1, "a"}
+	close(silent)
+	var got []string
+	for _, v := range drain(t, out) {
+		got = append(got, fmt.Sprintf("%d:%s", v.Source, v.Value.Name))
+	}
+	if fmt.Sprint(got) != "[1:a 0:b]" {
+		t.Errorf("got %v, want [1:a 0:b]", got)
+	}
+}
@@ -0,0 +1,269 @@
+package goroutines
+
+import (
+	"context" // Import the package for cancelling the stages
+	"sync"    // Import the package for synchronizing the workers
+	"time"    // Import the package for batching by time
+)
+
+// Every stage runs in its own goroutines and closes its output channels when its input is exhausted
+// or the context is cancelled, so a cancelled pipeline never leaks goroutines.
+
+// Function to send values to a channel, which is closed after the last value
+func Generate[T any](ctx context.Context, values ...T) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for _, v := range values {
+			select {
+			case out <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Function to read a channel until it is closed or the context is cancelled
+func OrDone[T any](ctx context.Context, in <-chan T) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					return
+				}
+				select {
+				case out <- v:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Function to apply a function to every value on the given number of workers. With more than one
+// worker the output order may differ from the input order.
+func Map[T, U any](ctx context.Context, in <-chan T, workers int, fn func(ctx context.Context, v T) U) <-chan U {
+	if workers <= 0 {
+		workers = 1
+	}
+
+	out := make(chan U)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for v := range OrDone(ctx, in) {
+				select {
+				case out <- fn(ctx, v):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	// Close the output once every worker is done
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// Function to pass on only the values the function keeps
+func Filter[T any](ctx context.Context, in <-chan T, keep func(v T) bool) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for v := range OrDone(ctx, in) {
+			if !keep(v) {
+				continue
+			}
+			select {
+			case out <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Function to group values into batches of at most size values. A batch is also sent when maxWait
+// passed since its first value; zero maxWait waits until the batch is full. The last partial batch
+// is sent when the input is closed.
+func Batch[T any](ctx context.Context, in <-chan T, size int, maxWait time.Duration) <-chan []T {
+	if size <= 0 {
+		size = 1
+	}
+
+	out := make(chan []T)
+	go func() {
+		defer close(out)
+
+		var batch []T
+		var timer *time.Timer
+		var expired <-chan time.Time // Nil, and so never ready, while the batch is empty
+
+		// Function to send the current batch and start a new one
+		flush := func() bool {
+			if timer != nil {
+				timer.Stop()
+				expired = nil
+			}
+			if len(batch) == 0 {
+				return true
+			}
+			select {
+			case out <- batch:
+				batch = nil
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					flush()
+					return
+				}
+				batch = append(batch, v)
+
+				// Start the timer with the first value of a batch
+				if len(batch) == 1 && maxWait > 0 {
+					timer = time.NewTimer(maxWait)
+					expired = timer.C
+				}
+				if len(batch) >= size && !flush() {
+					return
+				}
+			case <-expired:
+				if !flush() {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Function to spread the values over n channels; each value goes to whichever channel is read first
+func FanOut[T any](ctx context.Context, in <-chan T, n int) []<-chan T {
+	if n <= 0 {
+		n = 1
+	}
+
+	outs := make([]<-chan T, n)
+	for i := range outs {
+		// Every output competes for the values of the input
+		outs[i] = OrDone(ctx, in)
+	}
+	return outs
+}
+
+// Function to combine the values of several channels into one, closed when all of them are closed
+func FanIn[T any](ctx context.Context, ins ...<-chan T) <-chan T {
+	out := make(chan T)
+	var wg sync.WaitGroup
+	for _, in := range ins {
+		wg.Add(1)
+		go func(in <-chan T) {
+			defer wg.Done()
+			for v := range OrDone(ctx, in) {
+				select {
+				case out <- v:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(in)
+	}
+
+	// Close the output once every input is drained
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// Function to copy every value to two channels. A value is passed on only after both channels
+// received it, so the slower reader sets the pace.
+func Tee[T any](ctx context.Context, in <-chan T) (<-chan T, <-chan T) {
+	out1 := make(chan T)
+	out2 := make(chan T)
+	go func() {
+		defer close(out1)
+		defer close(out2)
+		for v := range OrDone(ctx, in) {
+			// Send to whichever channel is ready first, then to the other one
+			o1, o2 := out1, out2
+			for i := 0; i < 2; i++ {
+				select {
+				case o1 <- v:
+					o1 = nil // Set the channel to nil to avoid sending the value twice
+				case o2 <- v:
+					o2 = nil // Set the channel to nil to avoid sending the value twice
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out1, out2
+}
+
+// Function to demonstrate a pipeline built from the generic stages
+func PipelineExample() {
+	// Log a message about launching the pipeline example
+	logger().Info("Example of using pipeline stages")
+
+	// Cancel the whole pipeline when the function completes
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Generate the numbers from 1 to 20
+	numbers := Generate(ctx, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20)
+
+	// Square the numbers on 3 workers and keep the even squares
+	squares := Map(ctx, numbers, 3, func(ctx context.Context, n int) int { return n * n })
+	even := Filter(ctx, squares, func(n int) bool { return n%2 == 0 })
+
+	// Copy the even squares: one copy is summed, the other is split over 2 consumers and batched
+	toSum, toBatch := Tee(ctx, even)
+	consumers := FanOut(ctx, toBatch, 2)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sum := 0
+		for n := range toSum {
+			sum += n
+		}
+		logger().Info("Sum of the even squares", "sum", sum)
+	}()
+
+	// Combine the consumers again and group their values in batches of 4
+	for batch := range Batch(ctx, FanIn(ctx, consumers...), 4, 100*time.Millisecond) {
+		logger().Info("Received batch", "values", batch)
+	}
+
+	// Wait for the sum to be logged
+	wg.Wait()
+}
@@ -0,0 +1,281 @@
+package goroutines
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"testing"
+	"time"
+)
+
+// Function to read a channel until it is closed, failing the test when that takes too long
+func drain[T any](t *testing.T, in <-chan T) []T {
+	t.Helper()
+	var out []T
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case v, ok := <-in:
+			if !ok {
+				return out
+			}
+			out = append(out, v)
+		case <-timeout:
+			t.Fatal("channel was not closed")
+			return nil
+		}
+	}
+}
+
+// Function to wait until the number of goroutines is back at most at the given number
+func waitForGoroutines(t *testing.T, want int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > want {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			t.Fatalf("%d goroutines left, want at most %d\n%s", runtime.NumGoroutine(), want, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestGenerateAndOrDone(t *testing.T) {
+	ctx := context.Background()
+	if got := drain(t, OrDone(ctx, Generate(ctx, 1, 2, 3))); fmt.Sprint(got) != "[1 2 3]" {
+		t.Errorf("got %v, want [1 2 3]", got)
+	}
+	if got := drain(t, Generate[int](ctx)); len(got) != 0 {
+		t.Errorf("got %v from no values", got)
+	}
+}
+
+func TestMapAndFilterKeepOrderWithOneWorker(t *testing.T) {
+	ctx := context.Background()
+	squares := Map(ctx, Generate(ctx, 1, 2, 3, 4, 5, 6), 1, func(ctx context.Context, n int) int { return n * n })
+	even := Filter(ctx, squares, func(n int) bool { return n%2 == 0 })
+
+	if got := drain(t, even); fmt.Sprint(got) != "[4 16 36]" {
+		t.Errorf("got %v, want [4 16 36]", got)
+	}
+}
+
+func TestMapWorkers(t *testing.T) {
+	tests := []struct {
+		name    string
+		workers int
+	}{
+		{"zero workers use one", 0},
+		{"one worker", 1},
+		{"several workers", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			values := make([]int, 100)
+			for i := range values {
+				values[i] = i
+			}
+
+			got := drain(t, Map(ctx, Generate(ctx, values...), tt.workers, func(ctx context.Context, n int) int { return n * 2 }))
+			if tt.workers <= 1 {
+				// A single worker keeps the input order
+				for i, v := range got {
+					if v != i*2 {
+						t.Fatalf("value %d is %d, want %d", i, v, i*2)
+					}
+				}
+			}
+
+			// Every value arrives exactly once, whatever the order
+			sort.Ints(got)
+			if len(got) != len(values) {
+				t.Fatalf("got %d values, want %d", len(got), len(values))
+			}
+			for i, v := range got {
+				if v != i*2 {
+					t.Fatalf("sorted value %d is %d, want %d", i, v, i*2)
+				}
+			}
+		})
+	}
+}
+
+func TestBatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		maxWait time.Duration
+		send    func(in chan<- int) // Sends the values and closes the channel
+		want    string
+	}{
+		{
+			name: "full batches and a final partial batch",
+			size: 3,
+			send: func(in chan<- int) {
+				for i := 1; i <= 7; i++ {
+					in <- i
+				}
+				close(in)
+			},
+			want: "[[1 2 3] [4 5 6] [7]]",
+		},
+		{
+			name: "exact multiple of the size",
+			size: 2,
+			send: func(in chan<- int) {
+				for i := 1; i <= 4; i++ {
+					in <- i
+				}
+				close(in)
+			},
+			want: "[[1 2] [3 4]]",
+		},
+		{
+			name: "zero size uses one",
+			size: 0,
+			send: func(in chan<- int) {
+				in <- 1
+				in <- 2
+				close(in)
+			},
+			want: "[[1] [2]]",
+		},
+		{
+			name:    "flush on time before the batch is full",
+			size:    10,
+			maxWait: 20 * time.Millisecond,
+			send: func(in chan<- int) {
+				in <- 1
+				in <- 2
+				time.Sleep(100 * time.Millisecond) // Longer than maxWait
+				in <- 3
+				close(in)
+			},
+			want: "[[1 2] [3]]",
+		},
+		{
+			name: "no flush on time without maxWait",
+			size: 10,
+			send: func(in chan<- int) {
+				in <- 1
+				time.Sleep(50 * time.Millisecond)
+				in <- 2
+				close(in)
+			},
+			want: "[[1 2]]",
+		},
+		{
+			name: "empty input sends no batch",
+			size: 3,
+			send: func(in chan<- int) { close(in) },
+			want: "[]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := make(chan int)
+			go tt.send(in)
+
+			got := drain(t, Batch(context.Background(), in, tt.size, tt.maxWait))
+			if fmt.Sprint(got) != tt.want {
+				t.Errorf("got %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTeeDeliversToBothOutputs(t *testing.T) {
+	ctx := context.Background()
+	out1, out2 := Tee(ctx, Generate(ctx, 1, 2, 3, 4, 5))
+
+	// Read both outputs at the same time, as Tee waits for both readers
+	got2 := make(chan []int, 1)
+	go func() {
+		var got []int
+		for v := range out2 {
+			got = append(got, v)
+		}
+		got2 <- got
+	}()
+	got1 := drain(t, out1)
+
+	for i, got := range [][]int{got1, <-got2} {
+		if fmt.Sprint(got) != "[1 2 3 4 5]" {
+			t.Errorf("output %d got %v, want [1 2 3 4 5]", i+1, got)
+		}
+	}
+}
+
+func TestFanOutFanInKeepsEveryValue(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+	}{
+		{"zero outputs use one", 0},
+		{"one output", 1},
+		{"several outputs", 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			values := make([]int, 200)
+			for i := range values {
+				values[i] = i
+			}
+
+			outs := FanOut(ctx, Generate(ctx, values...), tt.n)
+			if want := max(tt.n, 1); len(outs) != want {
+				t.Fatalf("got %d outputs, want %d", len(outs), want)
+			}
+
+			got := drain(t, FanIn(ctx, outs...))
+			sort.Ints(got)
+			if len(got) != len(values) {
+				t.Fatalf("got %d values, want %d", len(got), len(values))
+			}
+			for i, v := range got {
+				if v != i {
+					t.Fatalf("sorted value %d is %d, want %d", i, v, i)
+				}
+			}
+		})
+	}
+}
+
+func TestCancellationClosesEveryOutput(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// An endless source that only stops when the context is cancelled
+	source := make(chan int)
+	go func() {
+		defer close(source)
+		for i := 0; ; i++ {
+			select {
+			case source <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	mapped := Map(ctx, source, 3, func(ctx context.Context, n int) int { return n })
+	filtered := Filter(ctx, mapped, func(n int) bool { return true })
+	out1, out2 := Tee(ctx, filtered)
+	fanned := FanOut(ctx, out1, 3)
+	merged := FanIn(ctx, fanned...)
+	batches := Batch(ctx, merged, 4, 10*time.Millisecond)
+	done := OrDone(ctx, out2)
+
+	// Read a little, leaving values in flight in every stage, then cancel
+	<-batches
+	<-done
+	cancel()
+
+	drain(t, batches)
+	drain(t, done)
+	waitForGoroutines(t, before)
+}
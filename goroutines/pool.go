@@ -0,0 +1,219 @@
+package goroutines
+
+import (
+	"context"       // Import the package for cancelling the pool
+	"errors"        // Import the package for working with errors
+	"fmt"           // Import the package for formatting panic values
+	"runtime"       // Import the package for the default number of workers
+	"runtime/debug" // Import the package for capturing the stack of a panic
+	"sort"          // Import the package for ordering the results
+	"sync"          // Import the package for synchronizing the workers
+)
+
+// Error returned when submitting a task after Wait was called
+var ErrPoolClosed = errors.New("worker pool is closed")
+
+// Error of a task that panicked, holding the panic value and the stack of the worker
+type PanicError struct {
+	Value any    // Value passed to panic
+	Stack []byte // Stack of the goroutine at the time of the panic
+}
+
+// Function to describe the panic
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("task panicked: %v", e.Value)
+}
+
+// Function to unwrap a panic raised with an error value
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Settings of a worker pool; zero values use the defaults
+type PoolOptions struct {
+	Workers     int  // Goroutines running tasks, defaults to the number of CPUs
+	QueueSize   int  // Tasks buffered before Submit blocks, zero hands each task directly to a worker
+	StopOnError bool // Cancel the pool after the first failed task, skipping the queued ones
+}
+
+// Unit of work run by a worker pool
+type Task[T any] func(ctx context.Context) (T, error)
+
+// Outcome of a task, Index is the order in which the task was submitted
+type Result[T any] struct {
+	Index int   // Submission order of the task, starting at 0
+	Value T     // Value returned by the task
+	Err   error // Error returned by the task, a *PanicError or the cancellation cause
+}
+
+// Task waiting in the queue together with its submission order
+type poolJob[T any] struct {
+	index int     // Submission order of the task
+	task  Task[T] // Task to run
+}
+
+// Bounded pool of goroutines running submitted tasks and collecting their results
+type Pool[T any] struct {
+	ctx    context.Context    // Cancelled with the parent, by StopOnError or by Cancel
+	cancel context.CancelFunc // Cancels ctx
+	opts   PoolOptions        // Settings of the pool
+	jobs   chan poolJob[T]    // Queue of submitted tasks
+	wg     sync.WaitGroup     // Running workers
+
+	submitMu sync.Mutex // Held while submitting and while closing the queue
+	closed   bool       // Whether Wait was called
+	next     int        // Submission order of the next task
+
+	mu       sync.Mutex  // Protects the fields below
+	results  []Result[T] // Results in completion order
+	firstErr error       // Error of the first failed task
+}
+
+// Function to create a worker pool and start its workers
+func NewPool[T any](ctx context.Context, opts PoolOptions) *Pool[T] {
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.NumCPU()
+	}
+	if opts.QueueSize < 0 {
+		opts.QueueSize = 0
+	}
+
+	p := &Pool[T]{opts: opts, jobs: make(chan poolJob[T], opts.QueueSize)}
+	p.ctx, p.cancel = context.WithCancel(ctx)
+
+	// Start the workers, each running tasks until the queue is closed
+	for i := 0; i < opts.Workers; i++ {
+		p.wg.Add(1)
+		go p.work()
+	}
+	return p
+}
+
+// Function to submit a task, blocking while the queue is full. It fails when the context or the pool
+// is cancelled before the task was queued, or when Wait was already called.
+func (p *Pool[T]) Submit(ctx context.Context, task Task[T]) error {
+	p.submitMu.Lock()
+	defer p.submitMu.Unlock()
+
+	if p.closed {
+		return ErrPoolClosed
+	}
+	if err := p.ctx.Err(); err != nil {
+		return err
+	}
+
+	select {
+	case p.jobs <- poolJob[T]{index: p.next, task: task}:
+		p.next++ // Only queued tasks are numbered, so rejected ones leave no gap
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.ctx.Done():
+		return p.ctx.Err()
+	}
+}
+
+// Function to cancel the pool: running tasks see their context cancelled and queued tasks are skipped
+func (p *Pool[T]) Cancel() {
+	p.cancel()
+}
+
+// Function to stop accepting tasks, wait for the queued ones and return every result in submission
+// order together with the error of the first failed task
+func (p *Pool[T]) Wait() ([]Result[T], error) {
+	p.submitMu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.jobs)
+	}
+	p.submitMu.Unlock()
+
+	p.wg.Wait()
+	p.cancel() // Release the resources of the pool context
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sort.Slice(p.results, func(i, j int) bool { return p.results[i].Index < p.results[j].Index })
+	return append([]Result[T](nil), p.results...), p.firstErr
+}
+
+// Function to run the tasks of the queue until it is closed
+func (p *Pool[T]) work() {
+	defer p.wg.Done()
+
+	for job := range p.jobs {
+		// Skip the queued tasks once the pool is cancelled
+		var result Result[T]
+		if err := p.ctx.Err(); err != nil {
+			result = Result[T]{Index: job.index, Err: err}
+		} else {
+			result = p.run(job)
+		}
+		p.record(result)
+	}
+}
+
+// Function to run a single task, turning a panic into a *PanicError
+func (p *Pool[T]) run(job poolJob[T]) (result Result[T]) {
+	result.Index = job.index
+	defer func() {
+		if v := recover(); v != nil {
+			result.Err = &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+
+	result.Value, result.Err = job.task(p.ctx)
+	return result
+}
+
+// Function to store the result of a task, remembering the first error
+func (p *Pool[T]) record(result Result[T]) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.results = append(p.results, result)
+	if result.Err == nil || p.firstErr != nil {
+		return
+	}
+
+	// The error is stored before cancelling, so the skipped tasks cannot take its place
+	p.firstErr = result.Err
+	if p.opts.StopOnError {
+		p.cancel()
+	}
+}
+
+// Function to demonstrate a bounded worker pool with a panicking task
+func WorkerPoolExample() {
+	// Log a message about launching the worker pool example
+	logger().Info("Example of using a bounded worker pool")
+
+	// Create a pool of 3 workers with room for 2 queued tasks
+	pool := NewPool[int](context.Background(), PoolOptions{Workers: 3, QueueSize: 2})
+
+	// Submit 10 tasks, Submit blocks while all workers are busy and the queue is full
+	for i := 1; i <= 10; i++ {
+		err := pool.Submit(context.Background(), func(ctx context.Context) (int, error) {
+			if i == 7 {
+				panic("unlucky number") // The panic is turned into an error of the task
+			}
+			return i * i, nil
+		})
+		if err != nil {
+			logger().Error("Error submitting the task", "task", i, "error", err)
+		}
+	}
+
+	// Wait for every task and log the results in submission order
+	results, err := pool.Wait()
+	for _, result := range results {
+		if result.Err != nil {
+			logger().Warn("Task failed", "task", result.Index+1, "error", result.Err)
+			continue
+		}
+		logger().Info("Task completed", "task", result.Index+1, "value", result.Value)
+	}
+	logger().Info("Worker pool finished", "tasks", len(results), "first_error", err)
+}
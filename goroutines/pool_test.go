@@ -0,0 +1,245 @@
+package goroutines
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// Function to create a task that waits until release is closed
+func blockingTask(started chan<- struct{}, release <-chan struct{}) Task[int] {
+	return func(ctx context.Context) (int, error) {
+		started <- struct{}{}
+		<-release
+		return 0, nil
+	}
+}
+
+func TestPoolSubmitBlocksWhenQueueIsFull(t *testing.T) {
+	pool := NewPool[int](context.Background(), PoolOptions{Workers: 1, QueueSize: 1})
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+
+	// Occupy the worker, then fill the queue
+	if err := pool.Submit(context.Background(), blockingTask(started, release)); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	if err := pool.Submit(context.Background(), func(ctx context.Context) (int, error) { return 1, nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	submitted := make(chan error, 1)
+	go func() {
+		submitted <- pool.Submit(context.Background(), func(ctx context.Context) (int, error) { return 2, nil })
+	}()
+
+	select {
+	case err := <-submitted:
+		t.Fatalf("Submit returned %v while the queue was full", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Freeing the worker makes room in the queue
+	close(release)
+	select {
+	case err := <-submitted:
+		if err != nil {
+			t.Fatalf("Submit failed: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Submit still blocked after the queue had room")
+	}
+
+	results, err := pool.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Errorf("got %d results, want 3", len(results))
+	}
+}
+
+func TestPoolSubmitFailsOnCancelledContext(t *testing.T) {
+	pool := NewPool[int](context.Background(), PoolOptions{Workers: 1})
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+
+	// With the only worker busy and no queue, Submit can only return through the context
+	if err := pool.Submit(context.Background(), blockingTask(started, release)); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := pool.Submit(ctx, func(ctx context.Context) (int, error) { return 1, nil })
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Submit returned %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	cancelled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+	err = pool.Submit(cancelled, func(ctx context.Context) (int, error) { return 1, nil })
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Submit returned %v, want %v", err, context.Canceled)
+	}
+
+	// The rejected tasks leave no results behind
+	close(release)
+	results, err := pool.Wait()
+	if err != nil || len(results) != 1 {
+		t.Errorf("Wait returned %d results and %v, want 1 result and no error", len(results), err)
+	}
+}
+
+func TestPoolSubmitFailsAfterCancel(t *testing.T) {
+	pool := NewPool[int](context.Background(), PoolOptions{Workers: 1})
+	pool.Cancel()
+
+	err := pool.Submit(context.Background(), func(ctx context.Context) (int, error) { return 1, nil })
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Submit returned %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestPoolSubmitAfterWait(t *testing.T) {
+	pool := NewPool[int](context.Background(), PoolOptions{Workers: 2})
+	if _, err := pool.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	err := pool.Submit(context.Background(), func(ctx context.Context) (int, error) { return 1, nil })
+	if !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Submit returned %v, want %v", err, ErrPoolClosed)
+	}
+
+	// Waiting again returns the same, empty, results
+	results, err := pool.Wait()
+	if err != nil || len(results) != 0 {
+		t.Errorf("second Wait returned %v and %v, want no results and no error", results, err)
+	}
+}
+
+func TestPoolPanicBecomesPanicError(t *testing.T) {
+	errCause := errors.New("cause")
+	tests := []struct {
+		name  string
+		value any
+		cause error
+	}{
+		{"string value", "unlucky number", nil},
+		{"error value", errCause, errCause},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := NewPool[int](context.Background(), PoolOptions{Workers: 1})
+			if err := pool.Submit(context.Background(), func(ctx context.Context) (int, error) { panic(tt.value) }); err != nil {
+				t.Fatal(err)
+			}
+
+			results, err := pool.Wait()
+			var panicErr *PanicError
+			if !errors.As(err, &panicErr) {
+				t.Fatalf("Wait returned %v, want a *PanicError", err)
+			}
+			if panicErr.Value != tt.value {
+				t.Errorf("panic value %v, want %v", panicErr.Value, tt.value)
+			}
+			if len(panicErr.Stack) == 0 {
+				t.Error("panic stack is empty")
+			}
+			if tt.cause != nil && !errors.Is(err, tt.cause) {
+				t.Errorf("%v does not unwrap to %v", err, tt.cause)
+			}
+			if len(results) != 1 || results[0].Err != err {
+				t.Errorf("results %v do not hold the panic error", results)
+			}
+		})
+	}
+}
+
+func TestPoolStopOnErrorSkipsQueuedTasks(t *testing.T) {
+	errBoom := errors.New("boom")
+	pool := NewPool[int](context.Background(), PoolOptions{Workers: 1, QueueSize: 5, StopOnError: true})
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+
+	// The failing task runs first and fails only once the other tasks are queued
+	err := pool.Submit(context.Background(), func(ctx context.Context) (int, error) {
+		started <- struct{}{}
+		<-release
+		return 0, errBoom
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	ran := make(chan int, 5)
+	for i := 1; i <= 5; i++ {
+		err := pool.Submit(context.Background(), func(ctx context.Context) (int, error) {
+			ran <- i
+			return i, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(release)
+
+	results, err := pool.Wait()
+	if !errors.Is(err, errBoom) {
+		t.Errorf("Wait returned %v, want %v", err, errBoom)
+	}
+	if len(results) != 6 {
+		t.Fatalf("got %d results, want 6", len(results))
+	}
+	if !errors.Is(results[0].Err, errBoom) {
+		t.Errorf("result 0 has error %v, want %v", results[0].Err, errBoom)
+	}
+	for _, result := range results[1:] {
+		if !errors.Is(result.Err, context.Canceled) {
+			t.Errorf("result %d has error %v, want %v", result.Index, result.Err, context.Canceled)
+		}
+	}
+	if len(ran) != 0 {
+		t.Errorf("%d queued tasks ran after the failure", len(ran))
+	}
+
+	// Wait closed the pool, so no more tasks are accepted
+	err = pool.Submit(context.Background(), func(ctx context.Context) (int, error) { return 0, nil })
+	if !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Submit returned %v, want %v", err, ErrPoolClosed)
+	}
+}
+
+func TestPoolResultsInSubmissionOrder(t *testing.T) {
+	pool := NewPool[int](context.Background(), PoolOptions{Workers: 4, QueueSize: 8})
+
+	const tasks = 50
+	for i := 0; i < tasks; i++ {
+		err := pool.Submit(context.Background(), func(ctx context.Context) (int, error) {
+			// Later tasks finish sooner, so completion order differs from submission order
+			time.Sleep(time.Duration(tasks-i) * 50 * time.Microsecond)
+			return i * i, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results, err := pool.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != tasks {
+		t.Fatalf("got %d results, want %d", len(results), tasks)
+	}
+	for i, result := range results {
+		if result.Index != i || result.Value != i*i || result.Err != nil {
+			t.Errorf("result %d is %+v, want index %d and value %d", i, result, i, i*i)
+		}
+	}
+}
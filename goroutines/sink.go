@@ -0,0 +1,101 @@
+package goroutines
+
+import (
+	"fmt"  // Import the package for formatting events
+	"io"   // Import the package for writing events
+	"sort" // Import the package for ordering collected events
+	"sync" // Import the package for protecting concurrent writes
+)
+
+// Value produced by a goroutine of an example
+type Event struct {
+	Msg    string `json:"msg"`    // What happened to the value, e.g. "Received"
+	Source int    `json:"source"` // Goroutine or channel the value came from, starting at 1
+	Value  int    `json:"value"`  // Produced value
+}
+
+// Function to format an event as a single line
+func (e Event) String() string {
+	return fmt.Sprintf("%s source=%d value=%d", e.Msg, e.Source, e.Value)
+}
+
+// Receiver of the events of the examples; Emit is called from several goroutines at once
+type Sink interface {
+	Emit(e Event)
+}
+
+// Function type usable as a Sink
+type SinkFunc func(e Event)
+
+// Function to pass an event to the function
+func (f SinkFunc) Emit(e Event) {
+	f(e)
+}
+
+// Function to create a sink logging every event with the package logger
+func LogSink() Sink {
+	return SinkFunc(func(e Event) {
+		logger().Info(e.Msg, "source", e.Source, "value", e.Value)
+	})
+}
+
+// Function to create a sink writing every event as a line, in the order the events arrive
+func WriterSink(w io.Writer) Sink {
+	var mu sync.Mutex
+	return SinkFunc(func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintln(w, e)
+	})
+}
+
+// Sink keeping the events, to return them in deterministic order once the goroutines are done
+type Collector struct {
+	mu     sync.Mutex // Protects the events
+	events []Event    // Events in the order they arrived
+}
+
+// Function to create an empty collector
+func NewCollector() *Collector {
+	return &Collector{}
+}
+
+// Function to keep an event
+func (c *Collector) Emit(e Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, e)
+}
+
+// Function to get the collected events ordered by source, value and message, which does not
+// depend on how the goroutines were scheduled
+func (c *Collector) Events() []Event {
+	c.mu.Lock()
+	events := append([]Event(nil), c.events...)
+	c.mu.Unlock()
+
+	sort.Slice(events, func(i, j int) bool {
+		a, b := events[i], events[j]
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		if a.Value != b.Value {
+			return a.Value < b.Value
+		}
+		return a.Msg < b.Msg
+	})
+	return events
+}
+
+// Function to write the collected events as lines in deterministic order
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	var written int64
+	for _, e := range c.Events() {
+		n, err := fmt.Fprintln(w, e)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
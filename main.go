@@ -1,6 +1,16 @@
 package main
 
 import (
+	"context"   // Import the package for waiting for an interrupt
+	"fmt"       // Import the package for formatted errors
+	"log"       // Import the package for logging errors
+	"log/slog"  // Import the package for structured logging
+	"os"        // Import the package for reading command-line arguments and the environment
+	"os/signal" // Import the package for handling Ctrl-C
+	"strings"   // Import the package for parsing the log format
+
+	// Import the package with the command-line interface
+	"nats_practice/cli"
 	// Import the package for working with goroutines
 	"nats_practice/goroutines"
 	// Import the package for working with NATS
@@ -8,6 +18,41 @@ import (
 )
 
 func main() {
+	// Run a single command when arguments are given instead of the examples
+	if len(os.Args) > 1 {
+		if err := cli.Run(os.Args[1:]); err != nil {
+			log.Fatal(err) // Log an error if the command fails
+		}
+		return
+	}
+
+	// Log in the format and at the level chosen by the environment
+	logger, err := newLogger(os.Getenv("LOG_FORMAT"), os.Getenv("LOG_LEVEL"))
+	if err != nil {
+		log.Fatal(err) // Log an error if the logging settings are invalid
+	}
+	slog.SetDefault(logger)
+	goroutines.SetLogger(logger)
+	nats_basic.SetLogger(logger)
+
+	// Export the spans of the examples as JSON lines to standard output or a file
+	if traceOutput := os.Getenv("TRACE_OUTPUT"); traceOutput != "" {
+		exporter, err := newSpanExporter(traceOutput)
+		if err != nil {
+			log.Fatal(err) // Log an error if the span file cannot be opened
+		}
+		defer exporter.Close()
+		nats_basic.DefaultTracer.SetExporter(exporter)
+	}
+
+	// Serve the metrics of the examples when an address is given, e.g. METRICS_ADDR=:9090
+	metricsAddr := os.Getenv("METRICS_ADDR")
+	if metricsAddr != "" {
+		server := nats_basic.ServeMetrics(metricsAddr, nats_basic.DefaultMetrics.Registry())
+		defer server.Close()
+		logger.Info("Serving metrics", "addr", metricsAddr, "path", "/metrics")
+	}
+
 	// Launch the function for creating and executing goroutines
 	goroutines.LaunchGoroutines()
 
@@ -20,15 +65,82 @@ func main() {
 	// Launch the function for working with the select operator
 	goroutines.SelectExample()
 
+	// Launch the function for merging any number of channels
+	goroutines.MergeExample()
+
+	// Launch the function for working with a bounded worker pool
+	goroutines.WorkerPoolExample()
+
+	// Launch the function for building a pipeline from generic stages
+	goroutines.PipelineExample()
+
+	// Launch the connection manager NATS example
+	nats_basic.ConnectionExample()
+
 	// Launch the Request-Reply NATS example
 	nats_basic.RequestReplyExample()
 
+	// Launch the typed RPC NATS example
+	nats_basic.TypedRPCExample()
+
+	// Launch the streaming reply NATS example
+	nats_basic.StreamReplyExample()
+
+	// Launch the NATS services example
+	nats_basic.ServiceExample()
+
+	// Launch the scatter-gather NATS example
+	nats_basic.ScatterGatherExample()
+
 	// Launch the Pub-Sub NATS example
 	nats_basic.PubSubExample()
 
+	// Launch the subject router NATS example
+	nats_basic.RouterExample()
+
 	// Launch the Queue Subscribe NATS example
 	nats_basic.QueueSubscribeExample()
 
+	// Launch the slow consumer NATS example
+	nats_basic.SlowConsumerExample()
+
 	// Launch the JetStream example
 	nats_basic.JetStreamExample()
+
+	// Keep the metrics available for scraping until interrupted
+	if metricsAddr != "" {
+		logger.Info("Examples finished, serving metrics until interrupted")
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		<-ctx.Done()
+	}
+}
+
+// Function to create the logger of the examples: format is "text" (default) or "json",
+// level is "debug", "info" (default), "warn" or "error"
+func newLogger(format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if level != "" {
+		if err := lvl.UnmarshalText([]byte(level)); err != nil {
+			return nil, err
+		}
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	switch strings.ToLower(format) {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(os.Stderr, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(os.Stderr, opts)), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q, use text or json", format)
+	}
+}
+
+// Function to create the span exporter of the examples: "stdout" or the path of a file to append to
+func newSpanExporter(output string) (*nats_basic.JSONSpanExporter, error) {
+	if output == "stdout" {
+		return nats_basic.NewJSONSpanExporter(os.Stdout), nil
+	}
+	return nats_basic.OpenJSONSpanExporter(output)
 }
@@ -0,0 +1,435 @@
+package nats_basic
+
+import (
+	"context"         // Import the package for cancelling a run
+	"crypto/rand"     // Import the package for naming the stream of a run
+	"encoding/binary" // Import the package for embedding send timestamps
+	"encoding/hex"    // Import the package for formatting the stream suffix
+	"errors"          // Import the package for working with errors
+	"fmt"             // Import the package for formatted input/output
+	"sort"            // Import the package for computing percentiles
+	"sync"            // Import the package for synchronizing the clients
+	"sync/atomic"     // Import the package for counting received messages
+	"time"            // Import the package for working with time
+
+	"github.com/nats-io/nats.go" // Import the package for working with NATS
+)
+
+// Modes of a benchmark run
+const (
+	BenchPubSub    = "pubsub"    // Every subscriber receives every message
+	BenchQueue     = "queue"     // Subscribers share the messages in a queue group
+	BenchRequest   = "request"   // Publishers send requests, subscribers in a queue group reply
+	BenchJetStream = "jetstream" // Publishers wait for stream acks, every subscriber receives every message
+)
+
+// Defaults of a benchmark run
+const (
+	DefaultBenchSubject = "bench"          // Subject the messages are published to
+	DefaultBenchCount   = 10000            // Messages sent by every publisher
+	DefaultBenchSize    = 128              // Bytes per message
+	DefaultBenchTimeout = 10 * time.Second // How long to wait for replies and subscribers
+	benchStampSize      = 8                // Bytes of the send timestamp at the start of every message
+	benchQueueGroup     = "bench"          // Queue group of the queue and request modes
+	benchStreamPrefix   = "BENCH_"         // Prefix of the stream created for the JetStream mode
+)
+
+// Settings of a benchmark run; zero values use the defaults
+type BenchOptions struct {
+	URL         string        // NATS server URL
+	Mode        string        // One of the Bench* modes
+	Subject     string        // Subject the messages are published to
+	Publishers  int           // Number of publishing clients, each with its own connection
+	Subscribers int           // Number of subscribing clients, each with its own connection
+	Count       int           // Messages sent by every publisher
+	Size        int           // Bytes per message, at least 8 for the send timestamp
+	Rate        int           // Messages per second of every publisher, zero publishes as fast as possible
+	Timeout     time.Duration // Timeout of a request or stream ack and how long to wait for the subscribers
+}
+
+// Results of a single client, or of all publishers or subscribers together. Like every time.Duration,
+// the duration and latencies are encoded in JSON as integer nanoseconds.
+type BenchResult struct {
+	Client     string        `json:"client"`       // Client name, e.g. pub-1 or sub-2
+	Msgs       int           `json:"msgs"`         // Messages sent or received
+	Bytes      int64         `json:"bytes"`        // Payload bytes sent or received
+	Errors     int           `json:"errors"`       // Failed publishes or requests
+	Duration   time.Duration `json:"duration"`     // Time between the first and the last message
+	MsgsPerSec float64       `json:"msgs_per_sec"` // Throughput in messages per second
+	MBPerSec   float64       `json:"mb_per_sec"`   // Throughput in megabytes per second
+	P50        time.Duration `json:"p50"`          // Median latency
+	P99        time.Duration `json:"p99"`          // 99th percentile latency
+	P999       time.Duration `json:"p999"`         // 99.9th percentile latency
+}
+
+// Results of a benchmark run. Publisher latency is the request round trip or the stream ack wait,
+// subscriber latency is the time from sending to receiving a message.
+type BenchReport struct {
+	Mode            string        `json:"mode"`             // Mode of the run
+	Subject         string        `json:"subject"`          // Subject the messages were published to
+	Size            int           `json:"size"`             // Bytes per message
+	Publishers      []BenchResult `json:"publishers"`       // Results of every publisher
+	Subscribers     []BenchResult `json:"subscribers"`      // Results of every subscriber
+	PublisherTotal  BenchResult   `json:"publisher_total"`  // Results of all publishers together
+	SubscriberTotal BenchResult   `json:"subscriber_total"` // Results of all subscribers together
+}
+
+// Messages and latencies recorded by a benchmark client
+type benchClient struct {
+	name     string          // Client name
+	received atomic.Int64    // Messages received, read while the run is in progress
+	mu       sync.Mutex      // Protects the fields below
+	msgs     int             // Messages sent or received
+	bytes    int64           // Payload bytes sent or received
+	errors   int             // Failed publishes or requests
+	first    time.Time       // Time of the first message
+	last     time.Time       // Time of the last message
+	samples  []time.Duration // Latency of every message
+}
+
+// Function to record a message sent or received at the given time
+func (c *benchClient) record(at time.Time, size int, latency time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.msgs == 0 {
+		c.first = at
+	}
+	c.last = at
+	c.msgs++
+	c.bytes += int64(size)
+	if latency > 0 {
+		c.samples = append(c.samples, latency)
+	}
+}
+
+// Function to record a failed publish or request
+func (c *benchClient) fail() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.errors++
+}
+
+// Function to run a benchmark and report the throughput and latency of every client
+func RunBench(ctx context.Context, opts BenchOptions) (*BenchReport, error) {
+	if opts.URL == "" {
+		opts.URL = nats.DefaultURL
+	}
+	if opts.Mode == "" {
+		opts.Mode = BenchPubSub
+	}
+	if opts.Subject == "" {
+		opts.Subject = DefaultBenchSubject
+	}
+	if opts.Publishers <= 0 {
+		opts.Publishers = 1
+	}
+	if opts.Count <= 0 {
+		opts.Count = DefaultBenchCount
+	}
+	if opts.Size == 0 {
+		opts.Size = DefaultBenchSize
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultBenchTimeout
+	}
+
+	switch {
+	case opts.Mode != BenchPubSub && opts.Mode != BenchQueue && opts.Mode != BenchRequest && opts.Mode != BenchJetStream:
+		return nil, fmt.Errorf("unknown benchmark mode %q", opts.Mode)
+	case opts.Size < benchStampSize:
+		return nil, fmt.Errorf("message size must be at least %d bytes", benchStampSize)
+	case opts.Subscribers < 0:
+		return nil, errors.New("number of subscribers must not be negative")
+	case opts.Mode == BenchRequest && opts.Subscribers == 0:
+		return nil, errors.New("request mode needs at least one subscriber to reply")
+	}
+
+	// Create an in-memory stream for the JetStream mode, removed when the run completes
+	if opts.Mode == BenchJetStream {
+		cleanup, err := createBenchStream(opts)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+	}
+
+	// Set up the subscribers first, so no message is published before they listen
+	subs := make([]*benchClient, opts.Subscribers)
+	for i := range subs {
+		subs[i] = &benchClient{name: fmt.Sprintf("sub-%d", i+1)}
+		nc, err := subscribeBench(opts, subs[i])
+		if err != nil {
+			return nil, err
+		}
+		defer nc.Close() // Close the subscriber connection when the run completes
+	}
+
+	// Connect the publishers before starting them, so connecting does not count
+	pubs := make([]*benchClient, opts.Publishers)
+	conns := make([]*nats.Conn, opts.Publishers)
+	for i := range pubs {
+		pubs[i] = &benchClient{name: fmt.Sprintf("pub-%d", i+1)}
+		nc, err := nats.Connect(opts.URL, nats.Name("bench-"+pubs[i].name))
+		if err != nil {
+			return nil, fmt.Errorf("connect to %s: %w", opts.URL, err)
+		}
+		defer nc.Close() // Close the publisher connection when the run completes
+		conns[i] = nc
+	}
+
+	// Publish from every publisher at the same time
+	var wg sync.WaitGroup
+	for i := range pubs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			publishBench(ctx, opts, conns[i], pubs[i])
+		}(i)
+	}
+	wg.Wait()
+
+	// Wait for the subscribers to receive the messages the publishers sent
+	waitForSubscribers(ctx, opts, pubs, subs)
+
+	report := &BenchReport{Mode: opts.Mode, Subject: opts.Subject, Size: opts.Size}
+	report.Publishers, report.PublisherTotal = summarizeBench(pubs, "publishers")
+	report.Subscribers, report.SubscriberTotal = summarizeBench(subs, "subscribers")
+	return report, nil
+}
+
+// Function to create the stream of the JetStream mode, returning a function removing it. Every run
+// creates a stream with a name of its own, so existing streams are never touched; a stream already
+// capturing the subject makes the run fail instead.
+func createBenchStream(opts BenchOptions) (func(), error) {
+	nc, err := nats.Connect(opts.URL)
+	if err != nil {
+		return nil, fmt.Errorf("connect to %s: %w", opts.URL, err)
+	}
+	js, err := nc.JetStream()
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("create JetStream context: %w", err)
+	}
+
+	suffix := make([]byte, 8)
+	rand.Read(suffix)
+	stream := benchStreamPrefix + hex.EncodeToString(suffix)
+	if _, err := js.AddStream(&nats.StreamConfig{
+		Name:     stream,
+		Subjects: []string{opts.Subject},
+		Storage:  nats.MemoryStorage,
+	}); err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("create stream %s for subject %s: %w", stream, opts.Subject, err)
+	}
+
+	return func() {
+		if err := js.DeleteStream(stream); err != nil {
+			logger().Warn("Error deleting the benchmark stream", "stream", stream, "error", err)
+		}
+		nc.Close()
+	}, nil
+}
+
+// Function to connect a subscriber and start receiving messages
+func subscribeBench(opts BenchOptions, client *benchClient) (*nats.Conn, error) {
+	nc, err := nats.Connect(opts.URL, nats.Name("bench-"+client.name))
+	if err != nil {
+		return nil, fmt.Errorf("connect to %s: %w", opts.URL, err)
+	}
+
+	handler := func(m *nats.Msg) {
+		now := time.Now()
+		client.record(now, len(m.Data), now.Sub(benchStamp(m.Data)))
+		client.received.Add(1)
+		if opts.Mode == BenchRequest {
+			m.Respond(m.Data) // Echo the request back to the publisher
+		}
+	}
+
+	var sub *nats.Subscription
+	switch opts.Mode {
+	case BenchQueue, BenchRequest:
+		sub, err = nc.QueueSubscribe(opts.Subject, benchQueueGroup, handler)
+	case BenchJetStream:
+		var js nats.JetStreamContext
+		if js, err = nc.JetStream(); err == nil {
+			sub, err = js.Subscribe(opts.Subject, handler, nats.DeliverNew(), nats.AckNone())
+		}
+	default:
+		sub, err = nc.Subscribe(opts.Subject, handler)
+	}
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("subscribe %s: %w", client.name, err)
+	}
+
+	// Buffer as much as needed, a benchmark should measure delays instead of dropping messages
+	if err := ApplyPendingLimits(sub, PendingLimits{Msgs: -1, Bytes: -1}); err != nil {
+		nc.Close()
+		return nil, err
+	}
+	if err := nc.Flush(); err != nil {
+		nc.Close()
+		return nil, err
+	}
+	return nc, nil
+}
+
+// Function to send the messages of a publisher at the configured rate
+func publishBench(ctx context.Context, opts BenchOptions, nc *nats.Conn, client *benchClient) {
+	var js nats.JetStreamContext
+	if opts.Mode == BenchJetStream {
+		var err error
+		if js, err = nc.JetStream(); err != nil {
+			client.fail()
+			return
+		}
+	}
+
+	// Space the messages evenly when a rate is set
+	var interval time.Duration
+	if opts.Rate > 0 {
+		interval = time.Second / time.Duration(opts.Rate)
+	}
+
+	start := time.Now()
+	for i := 0; i < opts.Count && ctx.Err() == nil; i++ {
+		if interval > 0 {
+			if wait := time.Until(start.Add(time.Duration(i) * interval)); wait > 0 {
+				time.Sleep(wait)
+			}
+		}
+
+		// Every message starts with its send time, so receivers can measure the latency
+		data := make([]byte, opts.Size)
+		sent := time.Now()
+		binary.BigEndian.PutUint64(data, uint64(sent.UnixNano()))
+
+		var err error
+		switch opts.Mode {
+		case BenchRequest:
+			_, err = nc.Request(opts.Subject, data, opts.Timeout)
+		case BenchJetStream:
+			// Wait for the ack at most for the timeout, like a request
+			pubCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+			_, err = js.Publish(opts.Subject, data, nats.Context(pubCtx))
+			cancel()
+		default:
+			err = nc.Publish(opts.Subject, data)
+		}
+		if err != nil {
+			client.fail()
+			continue
+		}
+
+		// Plain publishes return before delivery, so only replies and acks have a latency
+		var latency time.Duration
+		if opts.Mode == BenchRequest || opts.Mode == BenchJetStream {
+			latency = time.Since(sent)
+		}
+		client.record(time.Now(), opts.Size, latency)
+	}
+	nc.Flush() // Make sure every published message left the client
+}
+
+// Function to wait until the subscribers received every sent message, the context is cancelled
+// or no message arrived for the timeout
+func waitForSubscribers(ctx context.Context, opts BenchOptions, pubs, subs []*benchClient) {
+	if len(subs) == 0 {
+		return
+	}
+
+	// Every subscriber receives every message, unless the subscribers share them in a queue group
+	var sent int64
+	for _, pub := range pubs {
+		pub.mu.Lock()
+		sent += int64(pub.msgs)
+		pub.mu.Unlock()
+	}
+	shared := opts.Mode == BenchQueue || opts.Mode == BenchRequest
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	var lastTotal int64
+	lastProgress := time.Now()
+	for {
+		var total, fewest int64 = 0, sent
+		for _, sub := range subs {
+			n := sub.received.Load()
+			total += n
+			fewest = min(fewest, n)
+		}
+		if (shared && total >= sent) || (!shared && fewest >= sent) {
+			return
+		}
+
+		// Give up when the messages stopped arriving
+		if total != lastTotal {
+			lastTotal, lastProgress = total, time.Now()
+		} else if time.Since(lastProgress) > opts.Timeout {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Function to summarise every client and the clients together
+func summarizeBench(clients []*benchClient, totalName string) ([]BenchResult, BenchResult) {
+	results := make([]BenchResult, 0, len(clients))
+	total := benchClient{name: totalName}
+	for _, c := range clients {
+		c.mu.Lock()
+		results = append(results, benchResult(c.name, c.msgs, c.bytes, c.errors, c.last.Sub(c.first), c.samples))
+
+		// The clients together run from the earliest first message to the latest last message
+		if c.msgs > 0 {
+			if total.msgs == 0 || c.first.Before(total.first) {
+				total.first = c.first
+			}
+			if c.last.After(total.last) {
+				total.last = c.last
+			}
+		}
+		total.msgs += c.msgs
+		total.bytes += c.bytes
+		total.errors += c.errors
+		total.samples = append(total.samples, c.samples...)
+		c.mu.Unlock()
+	}
+	return results, benchResult(total.name, total.msgs, total.bytes, total.errors, total.last.Sub(total.first), total.samples)
+}
+
+// Function to compute the throughput and latency percentiles of a client
+func benchResult(name string, msgs int, bytes int64, errs int, duration time.Duration, samples []time.Duration) BenchResult {
+	result := BenchResult{Client: name, Msgs: msgs, Bytes: bytes, Errors: errs, Duration: duration}
+	if duration > 0 {
+		result.MsgsPerSec = float64(msgs) / duration.Seconds()
+		result.MBPerSec = float64(bytes) / duration.Seconds() / (1024 * 1024)
+	}
+
+	// Compute the percentiles over every sample
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	if len(sorted) > 0 {
+		result.P50 = sorted[len(sorted)*500/1000]
+		result.P99 = sorted[len(sorted)*990/1000]
+		result.P999 = sorted[len(sorted)*999/1000]
+	}
+	return result
+}
+
+// Function to read the send time at the start of a benchmark message
+func benchStamp(data []byte) time.Time {
+	if len(data) < benchStampSize {
+		return time.Now()
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(data)))
+}
@@ -0,0 +1,262 @@
+package nats_basic
+
+import (
+	"errors"   // Import the package for working with errors
+	"fmt"      // Import the package for formatted input/output
+	"log/slog" // Import the package for structured logging
+	"sync"     // Import the package for protecting the connection state
+	"time"     // Import the package for working with time
+
+	"github.com/nats-io/nats.go" // Import the package for working with NATS
+)
+
+// Defaults of the reconnect policy
+const (
+	DefaultMaxReconnects    = 60                     // Reconnect attempts before the connection is closed
+	DefaultReconnectWait    = 2 * time.Second        // Pause between attempts to the same server
+	DefaultReconnectJitter  = 500 * time.Millisecond // Random extra pause spreading reconnecting clients apart
+	DefaultReconnectBufSize = 8 * 1024 * 1024        // Bytes of outgoing messages buffered while reconnecting
+)
+
+// Kinds of connection events
+const (
+	EventConnected         = "connected"          // The first connection was established
+	EventDisconnected      = "disconnected"       // The connection to the server was lost
+	EventReconnected       = "reconnected"        // The connection was established again
+	EventClosed            = "closed"             // The connection was closed and will not reconnect
+	EventDiscoveredServers = "discovered_servers" // New servers of the cluster were discovered
+)
+
+// Structured event emitted for every connection state change
+type ConnectionEvent struct {
+	Type    string    `json:"type"`              // Kind of the event
+	Time    time.Time `json:"time"`              // Time of the event
+	URL     string    `json:"url,omitempty"`     // Server the connection is or was connected to
+	Servers []string  `json:"servers,omitempty"` // Servers known after discovery
+	Err     string    `json:"error,omitempty"`   // Error causing a disconnect or close
+}
+
+// Settings of a managed connection; zero values use the defaults
+type ConnectionOptions struct {
+	Name             string                      // Connection name shown by the server
+	MaxReconnects    int                         // Reconnect attempts, -1 reconnects forever
+	ReconnectWait    time.Duration               // Pause between attempts to the same server
+	ReconnectJitter  time.Duration               // Random extra pause added to ReconnectWait
+	ReconnectBufSize int                         // Bytes buffered while reconnecting, -1 disables buffering
+	OnEvent          func(event ConnectionEvent) // Optional receiver of the connection events
+	Options          []nats.Option               // Extra options such as an error handler
+}
+
+// Current state of a managed connection, for health checks
+type ConnectionState struct {
+	Status     string          `json:"status"`     // Connection status, e.g. CONNECTED or RECONNECTING
+	Healthy    bool            `json:"healthy"`    // Whether the connection is currently usable
+	URL        string          `json:"url"`        // Server the connection is connected to
+	Reconnects uint64          `json:"reconnects"` // Reconnects since the connection was created
+	LastEvent  ConnectionEvent `json:"last_event"` // Most recent connection event
+	LastError  string          `json:"last_error"` // Most recent error reported by an event
+}
+
+// Connection logging and emitting its lifecycle events, with a reconnect policy applied
+type ConnectionManager struct {
+	nc      *nats.Conn                  // Managed connection
+	onEvent func(event ConnectionEvent) // Optional receiver of the connection events
+	closed  chan struct{}               // Closed once the closed event was emitted
+
+	mu        sync.Mutex      // Protects the fields below
+	lastEvent ConnectionEvent // Most recent connection event
+	lastError string          // Most recent error reported by an event
+}
+
+// Function to connect to NATS with lifecycle event handlers and the reconnect policy
+func NewConnectionManager(url string, opts ConnectionOptions) (*ConnectionManager, error) {
+	if opts.MaxReconnects == 0 {
+		opts.MaxReconnects = DefaultMaxReconnects
+	}
+	if opts.ReconnectWait <= 0 {
+		opts.ReconnectWait = DefaultReconnectWait
+	}
+	if opts.ReconnectJitter <= 0 {
+		opts.ReconnectJitter = DefaultReconnectJitter
+	}
+	if opts.ReconnectBufSize == 0 {
+		opts.ReconnectBufSize = DefaultReconnectBufSize
+	}
+
+	m := &ConnectionManager{onEvent: opts.OnEvent, closed: make(chan struct{})}
+
+	natsOpts := []nats.Option{
+		nats.Name(opts.Name),
+		nats.MaxReconnects(opts.MaxReconnects),
+		nats.ReconnectWait(opts.ReconnectWait),
+		nats.ReconnectJitter(opts.ReconnectJitter, opts.ReconnectJitter),
+		nats.ReconnectBufSize(opts.ReconnectBufSize),
+		nats.DisconnectErrHandler(func(nc *nats.Conn, err error) {
+			m.emit(ConnectionEvent{Type: EventDisconnected, URL: nc.ConnectedUrl(), Err: errString(err)})
+		}),
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			m.emit(ConnectionEvent{Type: EventReconnected, URL: nc.ConnectedUrl()})
+		}),
+		nats.DiscoveredServersHandler(func(nc *nats.Conn) {
+			m.emit(ConnectionEvent{Type: EventDiscoveredServers, URL: nc.ConnectedUrl(), Servers: nc.DiscoveredServers()})
+		}),
+		nats.ClosedHandler(func(nc *nats.Conn) {
+			m.emit(ConnectionEvent{Type: EventClosed, Err: errString(nc.LastError())})
+			close(m.closed)
+		}),
+	}
+
+	nc, err := nats.Connect(url, append(natsOpts, opts.Options...)...)
+	if err != nil {
+		return nil, fmt.Errorf("connect to %s: %w", url, err)
+	}
+	m.nc = nc
+	m.emit(ConnectionEvent{Type: EventConnected, URL: nc.ConnectedUrl()})
+	return m, nil
+}
+
+// Function to get the managed connection
+func (m *ConnectionManager) Conn() *nats.Conn {
+	return m.nc
+}
+
+// Function to get the current state of the connection
+func (m *ConnectionManager) State() ConnectionState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return ConnectionState{
+		Status:     m.nc.Status().String(),
+		Healthy:    m.nc.IsConnected(),
+		URL:        m.nc.ConnectedUrl(),
+		Reconnects: m.nc.Stats().Reconnects,
+		LastEvent:  m.lastEvent,
+		LastError:  m.lastError,
+	}
+}
+
+// Function to check whether the connection is usable, returning the reason when it is not
+func (m *ConnectionManager) HealthCheck() error {
+	state := m.State()
+	if state.Healthy {
+		return nil
+	}
+	if state.LastError != "" {
+		return fmt.Errorf("connection %s: %s", state.Status, state.LastError)
+	}
+	return errors.New("connection " + state.Status)
+}
+
+// Function to drain the connection and wait for the closed event, at most for the given timeout
+func (m *ConnectionManager) Close(timeout time.Duration) error {
+	if err := m.nc.Drain(); err != nil {
+		m.nc.Close() // Close right away if draining is not possible
+	}
+
+	select {
+	case <-m.closed:
+		return nil
+	case <-time.After(timeout):
+		return errors.New("connection did not close before the timeout")
+	}
+}
+
+// Function to record, log and emit a connection event
+func (m *ConnectionManager) emit(event ConnectionEvent) {
+	event.Time = time.Now()
+
+	m.mu.Lock()
+	m.lastEvent = event
+	if event.Err != "" {
+		m.lastError = event.Err
+	}
+	m.mu.Unlock()
+
+	attrs := []any{slog.String("event", event.Type), slog.String("url", event.URL)}
+	if len(event.Servers) > 0 {
+		attrs = append(attrs, slog.Any("servers", event.Servers))
+	}
+	if event.Err != "" {
+		logger().Warn("NATS connection state changed", append(attrs, slog.String("error", event.Err))...)
+	} else {
+		logger().Info("NATS connection state changed", attrs...)
+	}
+	if m.onEvent != nil {
+		m.onEvent(event)
+	}
+}
+
+// Function to format an optional error
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// Time an example waits for its connection to drain before closing it right away
+const exampleCloseTimeout = 2 * time.Second
+
+// Function to connect an example through a connection manager, failing the example when it cannot
+// connect. The returned function drains and closes the connection.
+func connectExample(name string, opts ...nats.Option) (*nats.Conn, func()) {
+	m, err := NewConnectionManager(nats.DefaultURL, ConnectionOptions{Name: name, Options: opts})
+	if err != nil {
+		fatal("Error connecting to NATS server", err) // Log an error if the connection fails
+	}
+
+	closeConn := func() {
+		if err := m.Close(exampleCloseTimeout); err != nil {
+			logger().Warn("Error draining the connection", "name", name, "error", err)
+			m.Conn().Close() // Stop draining, dropping the pending messages
+		}
+	}
+	return m.Conn(), closeConn
+}
+
+// Function to demonstrate a managed connection with lifecycle events and health checks
+func ConnectionExample() {
+	// Log a message about launching the connection manager example
+	logger().Info("Example of managing NATS connections")
+
+	// Connect to NATS server with the reconnect policy, collecting the events
+	var events []ConnectionEvent
+	var mu sync.Mutex
+	m, err := NewConnectionManager(nats.DefaultURL, ConnectionOptions{
+		Name:             "connection-example",
+		MaxReconnects:    10,
+		ReconnectWait:    time.Second,
+		ReconnectJitter:  250 * time.Millisecond,
+		ReconnectBufSize: 1024 * 1024,
+		OnEvent: func(event ConnectionEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, event)
+		},
+	})
+	if err != nil {
+		fatal("Error connecting to NATS server", err) // Log an error if the connection fails
+	}
+
+	logger().Info("Connected to NATS server") // Message about successful connection
+
+	// Check the health of the connection
+	state := m.State()
+	logger().Info("Connection state", "status", state.Status, "healthy", state.Healthy, "url", state.URL, "reconnects", state.Reconnects) // Log the connection state
+	if err := m.HealthCheck(); err != nil {
+		fatal("Connection is not healthy", err) // Log an error if the connection is not healthy
+	}
+
+	// Close the connection and wait for the closed event
+	if err := m.Close(2 * time.Second); err != nil {
+		fatal("Error closing the connection", err) // Log an error if closing the connection fails
+	}
+	logger().Info("Health check after close", "error", m.HealthCheck()) // Log the failing health check
+
+	// Log the events emitted during the connection lifetime
+	mu.Lock()
+	defer mu.Unlock()
+	for _, event := range events {
+		logger().Info("Connection event", "event", event.Type, "time", event.Time) // Log every event
+	}
+}
@@ -1,42 +1,43 @@
 package nats_basic
 
 import (
-	"fmt"  // Import the package for formatted input/output
-	"log"  // Import the package for logging errors
-	"time" // Import the package for working with time
+	"bytes"   // Import the package for working with byte slices
+	"context" // Import the package for cancellation
+	"errors"  // Import the package for working with errors
+	"fmt"     // Import the package for formatted input/output
+	"io"      // Import the package for discarding downloaded data
+	"sync"    // Import the package for synchronizing goroutines
+	"time"    // Import the package for working with time
 
 	"github.com/nats-io/nats.go" // Import the package for working with NATS
 )
 
 // Function to setup JetStream stream and publish messages
 func JetStreamExample() {
-	// Print a message about launching the JetStream example
-	fmt.Println("\n--- Example of using JetStream NATS ---")
+	// Log a message about launching the JetStream example
+	logger().Info("Example of using JetStream NATS")
 
 	// Connect to NATS server
-	nc, err := nats.Connect(nats.DefaultURL)
-	if err != nil {
-		log.Fatal(err) // Log an error if the connection fails
-	}
-	defer nc.Close() // Close the connection when the function completes
+	nc, closeConn := connectExample("jetstream-example")
+	defer closeConn() // Drain and close the connection when the function completes
 
-	fmt.Println("Connected to NATS server") // Message about successful connection
+	logger().Info("Connected to NATS server") // Message about successful connection
 
 	// Create JetStream context
 	js, err := nc.JetStream()
 	if err != nil {
-		log.Fatal(err) // Log an error if creating the context fails
+		fatal("Error creating JetStream context", err) // Log an error if creating the context fails
 	}
 
-	fmt.Println("JetStream context created") // Message about successful context creation
+	logger().Info("JetStream context created") // Message about successful context creation
 
 	// Check JetStream availability
 	accountInfo, err := js.AccountInfo()
 	if err != nil {
-		log.Fatal("Error fetching JetStream account info: ", err) // Log an error if fetching account info fails
+		fatal("Error fetching JetStream account info", err) // Log an error if fetching account info fails
 	}
 
-	fmt.Printf("JetStream is available: %+v\n", accountInfo) // Print account info for JetStream
+	logger().Info("JetStream is available", "streams", accountInfo.Streams, "consumers", accountInfo.Consumers, "memory", accountInfo.Memory, "storage", accountInfo.Store) // Log account info for JetStream
 
 	// Define stream configuration
 	streamConfig := &nats.StreamConfig{
@@ -49,20 +50,23 @@ func JetStreamExample() {
 	// Add stream
 	streamInfo, err := js.AddStream(streamConfig)
 	if err != nil {
-		log.Fatal("Error adding stream: ", err) // Log an error if adding the stream fails
+		fatal("Error adding stream", err) // Log an error if adding the stream fails
 	}
 
-	fmt.Println("Stream added: ", streamInfo.Config.Name) // Message about successful stream addition
+	logger().Info("Stream added", "stream", streamInfo.Config.Name) // Message about successful stream addition
 
 	// Publish messages to the stream
 	for i := 1; i <= 5; i++ {
-		subject := fmt.Sprintf("orders.%d", i) // Define the subject of the message
-		message := fmt.Sprintf("Order %d", i)  // Define the message
-		ack, err := js.Publish(subject, []byte(message))
+		subject := fmt.Sprintf("orders.%d", i)                                                  // Define the subject of the message
+		message := fmt.Sprintf("Order %d", i)                                                   // Define the message
+		ack, err := DefaultTracer.PublishJS(context.Background(), js, subject, []byte(message)) // Start a trace per order
 		if err != nil {
-			log.Fatal("Error publishing message: ", err) // Log an error if publishing the message fails
+			fatal("Error publishing message", err) // Log an error if publishing the message fails
 		}
-		fmt.Printf("Published message: %s to subject: %s, ack: %v\n", message, subject, ack) // Message about successful publication
+
+		// Count the stored message
+		DefaultMetrics.Published.Inc(subject)
+		logger().Info("Published message", "subject", subject, "stream", ack.Stream, "sequence", ack.Sequence, "data", message) // Message about successful publication
 	}
 
 	// Allow some time for messages to be processed
@@ -76,46 +80,78 @@ func JetStreamExample() {
 
 	consumerInfo, err := js.AddConsumer("ORDERS", consumerConfig)
 	if err != nil {
-		log.Fatal("Error adding consumer: ", err) // Log an error if adding the consumer fails
+		fatal("Error adding consumer", err) // Log an error if adding the consumer fails
 	}
 
-	fmt.Println("Consumer added: ", consumerInfo.Name) // Message about successful consumer addition
+	logger().Info("Consumer added", "stream", consumerInfo.Stream, "consumer", consumerInfo.Name) // Message about successful consumer addition
+
+	// Report the lag of the consumer
+	DefaultMetrics.WatchConsumer(js, "ORDERS", consumerInfo.Name)
+
+	// Setup a responder reserving stock for the orders, continuing their traces
+	_, err = nc.Subscribe("inventory.reserve", DefaultTracer.Handler(func(ctx context.Context, m *nats.Msg) {
+		m.Respond([]byte("reserved")) // Confirm the reservation
+	}))
+	if err != nil {
+		fatal("Error setting up the responder", err) // Log an error if setting up the responder fails
+	}
 
 	// Subscribe to the consumer
 	sub, err := js.PullSubscribe("orders.*", "ORDER_CONSUMER")
 	if err != nil {
-		log.Fatal("Error subscribing to consumer: ", err) // Log an error if subscribing to the consumer fails
+		fatal("Error subscribing to consumer", err) // Log an error if subscribing to the consumer fails
 	}
 
-	fmt.Println("Subscribed to the consumer") // Message about successful subscription
+	logger().Info("Subscribed to the consumer") // Message about successful subscription
 
 	// Fetch messages from the consumer with increased timeout
 	msgs, err := sub.Fetch(5, nats.MaxWait(20*time.Second))
 	if err != nil {
-		log.Fatal("Error fetching messages: ", err) // Log an error if fetching messages fails
+		fatal("Error fetching messages", err) // Log an error if fetching messages fails
 	}
 
 	for _, msg := range msgs {
-		fmt.Printf("Received message: %s\n", string(msg.Data)) // Print the received message
-		msg.Ack()                                              // Acknowledge the message
+		// Handle the order in a span continuing the trace of its publisher
+		ctx, span := DefaultTracer.StartMsgSpan(context.Background(), msg, SpanKindConsumer)
+		logger().Info("Received message", append(msgAttrs(msg), "data", string(msg.Data))...) // Log the received message
+
+		// Reserve stock for the order within the same trace, waiting at most 2 seconds for the reply
+		reqCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		reply, err := DefaultTracer.Request(reqCtx, nc, "inventory.reserve", msg.Data)
+		cancel()
+		if err != nil {
+			fatal("Error reserving stock", err) // Log an error if the reservation fails
+		}
+		logger().Info("Stock reserved", append(msgAttrs(msg), "reply", string(reply.Data))...) // Log the reservation
+
+		DefaultMetrics.Ack(msg) // Acknowledge and count the message
+		span.End(nil)
 	}
 
-	fmt.Println("Messages fetched and acknowledged") // Message about successful message fetching and acknowledgment
+	logger().Info("Messages fetched and acknowledged") // Message about successful message fetching and acknowledgment
 
 	// Object store operations
-	fmt.Println("\n--- Working with the object store ---")
+	logger().Info("Working with the object store")
 	objectStoreExample(js)
 
 	// Key-value store operations
-	fmt.Println("\n--- Working with the key-value store ---")
+	logger().Info("Working with the key-value store")
 	keyValueStoreExample(js)
 
+	// Distributed lock and leader election on top of the key-value store
+	logger().Info("Distributed lock and leader election")
+	distributedLockExample(js)
+
+	// Hot-reloadable configuration on top of the key-value store
+	logger().Info("Hot-reloadable configuration")
+	configExample(js)
+
 	// Filtered subject consumer
-	fmt.Println("\n--- Filtering subjects for consumers ---")
+	logger().Info("Filtering subjects for consumers")
 	filteredSubjectConsumer(js)
 
 	// Consumer with ack wait and max deliver settings
-	fmt.Println("\n--- Configuring consumers with ack wait and max deliver ---")
+	logger().Info("Configuring consumers with ack wait and max deliver")
 	consumerWithAckWaitAndMaxDeliver(js)
 }
 
@@ -128,73 +164,364 @@ func objectStoreExample(js nats.JetStreamContext) {
 
 	objStore, err := js.CreateObjectStore(objStoreConfig)
 	if err != nil {
-		log.Fatal("Error creating object store: ", err) // Log an error if creating the object store fails
+		fatal("Error creating object store", err) // Log an error if creating the object store fails
+	}
+
+	logger().Info("Object store created") // Message about successful object store creation
+
+	// Watch the store for added and deleted objects in the background
+	watchCtx, stopWatching := context.WithCancel(context.Background())
+	defer stopWatching()
+	go WatchObjects(watchCtx, objStore, func(event ObjectEvent) {
+		logger().Info("Object store event", "kind", event.Kind, "object", event.Info.Name) // Log the change
+	})
+
+	// Stream an object into the store in small chunks, reporting progress
+	data := bytes.Repeat([]byte("This is a test object. "), 20000)
+	transferOptions := ObjectTransferOptions{
+		ChunkSize: 64 * 1024,              // Split the object into 64 KB chunks
+		Attempts:  3,                      // Retry failed transfers
+		RetryWait: 500 * time.Millisecond, // Pause between attempts
+		Progress: func(done, total int64) {
+			if done == total {
+				logger().Info("Object transferred", "done", done, "total", total) // Log the progress once complete
+			}
+		},
+	}
+
+	info, err := PutObjectStream(objStore, "my_object", bytes.NewReader(data), int64(len(data)), transferOptions)
+	if err != nil {
+		fatal("Error putting object in store", err) // Log an error if putting the object fails
+	}
+
+	logger().Info("Object stored successfully", "object", info.Name, "bytes", info.Size, "chunks", info.Chunks, "digest", info.Digest) // Message about successful object storage
+
+	// Stream the object back out of the store, verifying its digest
+	var downloaded bytes.Buffer
+	_, err = GetObjectStream(js, objStore, "my_object", &downloaded, transferOptions)
+	if err != nil {
+		fatal("Error getting object from store", err) // Log an error if getting the object fails
+	}
+
+	logger().Info("Retrieved object", "object", "my_object", "bytes", downloaded.Len(), "matches", bytes.Equal(downloaded.Bytes(), data)) // Log the size of the retrieved object
+
+	// Describe the object and attach custom headers
+	err = UpdateObjectMeta(objStore, "my_object", "Repeated test text", map[string]string{"Content-Type": "text/plain"})
+	if err != nil {
+		fatal("Error updating object metadata", err) // Log an error if updating the metadata fails
+	}
+
+	// Link to the object from another name
+	_, err = LinkObject(objStore, "my_object_link", "my_object")
+	if err != nil {
+		fatal("Error linking object", err) // Log an error if linking the object fails
 	}
 
-	fmt.Println("Object store created") // Message about successful object store creation
+	// Link to a whole other bucket
+	archive, err := js.CreateObjectStore(&nats.ObjectStoreConfig{Bucket: "MY_ARCHIVE"})
+	if err != nil {
+		fatal("Error creating archive object store", err) // Log an error if creating the archive fails
+	}
+	_, err = LinkBucket(objStore, "archive", archive)
+	if err != nil {
+		fatal("Error linking bucket", err) // Log an error if linking the bucket fails
+	}
 
-	// Put an object in the store
-	_, err = objStore.PutBytes("my_object", []byte("This is a test object"))
+	// Link to an object stored in the archive
+	archived, err := archive.PutBytes("archived_object", []byte("Archived text"))
+	if err != nil {
+		fatal("Error putting object in archive", err) // Log an error if putting the archived object fails
+	}
+	_, err = objStore.AddLink("archived_link", archived)
 	if err != nil {
-		log.Fatal("Error putting object in store: ", err) // Log an error if putting the object fails
+		fatal("Error linking archived object", err) // Log an error if linking the archived object fails
 	}
 
-	fmt.Println("Object stored successfully") // Message about successful object storage
+	// Download through the links, which resolve to their targets
+	for _, link := range []string{"my_object_link", "archived_link"} {
+		var linked bytes.Buffer
+		target, err := GetObjectStream(js, objStore, link, &linked, ObjectTransferOptions{})
+		if err != nil {
+			fatal("Error getting object through link", err, "link", link) // Log an error if getting the linked object fails
+		}
+		logger().Info("Retrieved object through link", "link", link, "bucket", target.Bucket, "object", target.Name, "bytes", linked.Len()) // Log the resolved target
+	}
+
+	// A link to a whole bucket has no content of its own
+	_, err = GetObjectStream(js, objStore, "archive", io.Discard, ObjectTransferOptions{})
+	logger().Info("Getting a bucket link fails", "link", "archive", "error", err) // Log the expected error
 
-	// Get the object from the store
-	obj, err := objStore.GetBytes("my_object")
+	// List the objects with their metadata
+	objects, err := ListObjects(objStore)
 	if err != nil {
-		log.Fatal("Error getting object from store: ", err) // Log an error if getting the object fails
+		fatal("Error listing objects", err) // Log an error if listing the objects fails
 	}
 
-	fmt.Printf("Retrieved object: %s\n", string(obj)) // Print the retrieved object
+	for _, object := range objects {
+		logger().Info("Object", "object", object.Name, "size", object.Size, "modified", object.Modified, "digest", object.Digest,
+			"description", object.Description, "headers", object.Headers, "link", object.Link) // Log the object summary
+	}
+
+	// Remove the links again
+	for _, link := range []string{"my_object_link", "archived_link", "archive"} {
+		if err := objStore.Delete(link); err != nil {
+			fatal("Error deleting link", err) // Log an error if deleting the link fails
+		}
+	}
 
 	// Delete the object from the store
 	err = objStore.Delete("my_object")
 	if err != nil {
-		log.Fatal("Error deleting object from store: ", err) // Log an error if deleting the object fails
+		fatal("Error deleting object from store", err) // Log an error if deleting the object fails
 	}
 
-	fmt.Println("Object deleted successfully") // Message about successful object deletion
+	logger().Info("Object deleted successfully") // Message about successful object deletion
+
+	// Allow some time for the watcher to report the changes
+	time.Sleep(500 * time.Millisecond)
 }
 
 // Function to demonstrate key-value store operations
 func keyValueStoreExample(js nats.JetStreamContext) {
 	// Create a key-value store
-	kvStoreConfig := &nats.KeyValueConfig{
-		Bucket: "MY_KV_BUCKET", // Key-value store bucket name
+	kvStoreOptions := KVBucketOptions{
+		Bucket:       "MY_KV_BUCKET",   // Key-value store bucket name
+		History:      KVHistory,        // Number of revisions kept per key
+		TTL:          24 * time.Hour,   // Values expire after a day
+		MaxValueSize: 1024,             // Largest value accepted
+		MaxBytes:     1024 * 1024,      // Largest total size of the bucket
+		Storage:      nats.FileStorage, // File storage type
+		Replicas:     1,                // Single replica
 	}
 
-	kvStore, err := js.CreateKeyValue(kvStoreConfig)
+	kvStore, err := CreateKVBucket(js, kvStoreOptions)
 	if err != nil {
-		log.Fatal("Error creating key-value store: ", err) // Log an error if creating the key-value store fails
+		fatal("Error creating key-value store", err) // Log an error if creating the key-value store fails
+	}
+
+	logger().Info("Key-Value store created") // Message about successful key-value store creation
+
+	// Create a key-value pair only if the key does not exist yet
+	revision, err := CreateKey(kvStore, "my_key", []byte("This is a test value"))
+	if err != nil {
+		fatal("Error putting key-value pair in store", err) // Log an error if creating the key-value pair fails
+	}
+
+	logger().Info("Key-Value pair stored successfully", "key", "my_key", "revision", revision) // Message about successful key-value pair storage
+
+	// Update the key guarded by the revision we just wrote
+	revision, err = UpdateKey(kvStore, "my_key", []byte("This is an updated value"), revision)
+	if err != nil {
+		fatal("Error updating key-value pair in store", err) // Log an error if updating the key-value pair fails
+	}
+
+	logger().Info("Key-Value pair updated", "key", "my_key", "revision", revision) // Message about successful key-value pair update
+
+	// Updating with a stale revision is rejected
+	_, err = UpdateKey(kvStore, "my_key", []byte("This is a stale value"), revision-1)
+	if !IsRevisionConflict(err) {
+		fatal("Expected a revision conflict, got", err) // Log an error if the stale update was not rejected
 	}
 
-	fmt.Println("Key-Value store created") // Message about successful key-value store creation
+	logger().Info("Stale update rejected", "key", "my_key", "error", err) // Message about the rejected stale update
 
-	// Put a key-value pair in the store
-	_, err = kvStore.Put("my_key", []byte("This is a test value"))
+	// Read-modify-write that retries on concurrent updates
+	revision, err = UpdateKeyWithRetry(kvStore, "my_key", DefaultUpdateRetries, func(current []byte) ([]byte, error) {
+		return append(current, " (modified)"...), nil
+	})
 	if err != nil {
-		log.Fatal("Error putting key-value pair in store: ", err) // Log an error if putting the key-value pair fails
+		fatal("Error modifying key-value pair in store", err) // Log an error if the read-modify-write fails
 	}
 
-	fmt.Println("Key-Value pair stored successfully") // Message about successful key-value pair storage
+	logger().Info("Key-Value pair modified", "key", "my_key", "revision", revision) // Message about successful read-modify-write
 
 	// Get the value from the store
 	kvEntry, err := kvStore.Get("my_key")
 	if err != nil {
-		log.Fatal("Error getting key-value pair from store: ", err) // Log an error if getting the key-value pair fails
+		fatal("Error getting key-value pair from store", err) // Log an error if getting the key-value pair fails
 	}
 
-	fmt.Printf("Retrieved key-value pair: key=%s, value=%s\n", kvEntry.Key(), string(kvEntry.Value())) // Print the retrieved key-value pair
+	logger().Info("Retrieved key-value pair", "bucket", kvEntry.Bucket(), "key", kvEntry.Key(), "revision", kvEntry.Revision(), "value", string(kvEntry.Value())) // Log the retrieved key-value pair
 
 	// Delete the key-value pair from the store
 	err = kvStore.Delete("my_key")
 	if err != nil {
-		log.Fatal("Error deleting key-value pair from store: ", err) // Log an error if deleting the key-value pair fails
+		fatal("Error deleting key-value pair from store", err) // Log an error if deleting the key-value pair fails
+	}
+
+	logger().Info("Key-Value pair deleted successfully") // Message about successful key-value pair deletion
+
+	// List every revision of the key, including the delete marker
+	history, err := KeyHistory(kvStore, "my_key")
+	if err != nil {
+		fatal("Error getting key history", err) // Log an error if getting the history fails
+	}
+
+	for _, entry := range history {
+		logger().Info("Key revision", "bucket", entry.Bucket(), "key", entry.Key(), "revision", entry.Revision(),
+			"operation", OperationName(entry.Operation()), "value", string(entry.Value())) // Log every revision of the key
+	}
+
+	// Purge the key, dropping all its revisions
+	err = PurgeKey(kvStore, "my_key")
+	if err != nil {
+		fatal("Error purging key", err) // Log an error if purging the key fails
+	}
+
+	logger().Info("Key purged successfully") // Message about successful purge
+
+	// Remove all delete and purge markers
+	err = CompactDeletes(kvStore, -1)
+	if err != nil {
+		fatal("Error compacting delete markers", err) // Log an error if compacting the markers fails
+	}
+
+	logger().Info("Delete markers compacted") // Message about successful compaction
+
+	// Report the state of the bucket
+	report, err := KVStatus(kvStore)
+	if err != nil {
+		fatal("Error getting key-value store status", err) // Log an error if getting the status fails
+	}
+
+	logger().Info("Key-value store status", "bucket", report.Bucket, "stream", report.Stream, "values", report.Values,
+		"bytes", report.Bytes, "history", report.History, "ttl", report.TTL) // Log the status of the bucket
+}
+
+// Function to demonstrate a lease-based lock and leader election
+func distributedLockExample(js nats.JetStreamContext) {
+	lease := 3 * time.Second // Locks expire unless renewed within this time
+
+	// Create a bucket for locks
+	lockBucket, err := CreateLockBucket(js, "MY_LOCKS", lease)
+	if err != nil {
+		fatal("Error creating lock bucket", err) // Log an error if creating the lock bucket fails
+	}
+
+	logger().Info("Lock bucket created") // Message about successful lock bucket creation
+
+	// Two workers compete for the same lock
+	lock1 := NewKVLock(lockBucket, "singleton", "worker-1")
+	lock2 := NewKVLock(lockBucket, "singleton", "worker-2")
+
+	ok1, err := lock1.TryAcquire()
+	if err != nil {
+		fatal("Error acquiring lock", err) // Log an error if acquiring the lock fails
+	}
+	ok2, err := lock2.TryAcquire()
+	if err != nil {
+		fatal("Error acquiring lock", err) // Log an error if acquiring the lock fails
+	}
+
+	logger().Info("Lock acquisition", "key", "singleton", "worker_1", ok1, "worker_2", ok2) // Only one worker gets the lock
+
+	// Extend the lease and give the lock up
+	if err := lock1.Renew(); err != nil {
+		fatal("Error renewing lock", err) // Log an error if renewing the lock fails
+	}
+	if err := lock1.Release(); err != nil {
+		fatal("Error releasing lock", err) // Log an error if releasing the lock fails
+	}
+
+	logger().Info("Lock renewed and released") // Message about successful renew and release
+
+	// Two candidates campaign for leadership for a short while
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, candidate := range []string{"worker-1", "worker-2"} {
+		wg.Add(1)
+		go func(candidate string) {
+			defer wg.Done()
+			election := NewLeaderElection(lockBucket, "leader", candidate, lease,
+				func(ctx context.Context) { logger().Info("Became the leader", "candidate", candidate) },
+				func() { logger().Info("Stepped down", "candidate", candidate) },
+			)
+			election.Run(ctx) // Campaign until the context times out
+		}(candidate)
+	}
+
+	// Wait for both candidates to finish
+	wg.Wait()
+}
+
+// Settings of the example application, loaded from the config bucket
+type exampleSettings struct {
+	Workers      int           `kv:"workers"`       // Number of queue workers
+	Greeting     string        `kv:"greeting"`      // Greeting printed by the workers
+	PollInterval time.Duration `kv:"poll_interval"` // How often the workers poll
+}
+
+// Function to demonstrate loading, validating and hot-reloading settings
+func configExample(js nats.JetStreamContext) {
+	// Create a bucket for the settings
+	configBucket, err := CreateKVBucket(js, KVBucketOptions{
+		Bucket:  "MY_CONFIG",      // Config bucket name
+		History: KVHistory,        // Keep previous settings for auditing
+		Storage: nats.FileStorage, // File storage type
+	})
+	if err != nil {
+		fatal("Error creating config bucket", err) // Log an error if creating the config bucket fails
+	}
+
+	// Store the initial settings
+	err = WriteConfigValues(configBucket, map[string]string{"workers": "2", "greeting": "hello", "poll_interval": "1s"})
+	if err != nil {
+		fatal("Error writing settings", err) // Log an error if writing the settings fails
+	}
+
+	// Create a client that rejects settings without workers
+	defaults := exampleSettings{Workers: 1, Greeting: "hi", PollInterval: time.Second}
+	client := NewConfigClient(configBucket, defaults, func(s *exampleSettings) error {
+		if s.Workers < 1 {
+			return fmt.Errorf("workers must be positive, got %d", s.Workers)
+		}
+		return nil
+	})
+
+	// Report reloads and rejected changes
+	reloaded := make(chan struct{}, 10)
+	client.OnReload(func(old, new *exampleSettings) {
+		logger().Info("Settings reloaded", "old", *old, "new", *new) // Log the old and new settings
+		reloaded <- struct{}{}
+	})
+	client.OnError(func(err error) {
+		logger().Warn("Settings change rejected", "error", err) // Log why the change was rejected
+		reloaded <- struct{}{}
+	})
+
+	// Watch the bucket in the background, reporting why the watch stopped
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- client.Watch(ctx)
+	}()
+
+	// Function to wait for the next reload or rejected change
+	waitForReload := func() {
+		select {
+		case <-reloaded:
+		case err := <-watchErr:
+			fatal("Error watching settings", err) // Log an error if the watch stopped
+		case <-time.After(5 * time.Second):
+			fatal("Error watching settings", errors.New("no reload within 5s")) // Log an error if no reload arrived
+		}
+	}
+
+	// Wait for the initial load, then change and break a setting
+	waitForReload()
+	if _, err := configBucket.PutString("workers", "4"); err != nil {
+		fatal("Error updating setting", err) // Log an error if updating the setting fails
+	}
+	waitForReload()
+	if _, err := configBucket.PutString("workers", "0"); err != nil {
+		fatal("Error updating setting", err) // Log an error if updating the setting fails
 	}
+	waitForReload()
 
-	fmt.Println("Key-Value pair deleted successfully") // Message about successful key-value pair deletion
+	logger().Info("Current settings", "settings", *client.Current()) // The invalid change was not applied
 }
 
 // Function to demonstrate filtered subject consumer
@@ -208,31 +535,32 @@ func filteredSubjectConsumer(js nats.JetStreamContext) {
 
 	consumerInfo, err := js.AddConsumer("ORDERS", consumerConfig)
 	if err != nil {
-		log.Fatal("Error adding filtered consumer: ", err) // Log an error if adding the filtered consumer fails
+		fatal("Error adding filtered consumer", err) // Log an error if adding the filtered consumer fails
 	}
 
-	fmt.Println("Filtered consumer added: ", consumerInfo.Name) // Message about successful filtered consumer addition
+	logger().Info("Filtered consumer added", "stream", consumerInfo.Stream, "consumer", consumerInfo.Name) // Message about successful filtered consumer addition
+	DefaultMetrics.WatchConsumer(js, "ORDERS", consumerInfo.Name)                                          // Report the lag of the consumer
 
 	// Subscribe to the filtered consumer
 	sub, err := js.PullSubscribe("orders.1", "FILTERED_CONSUMER")
 	if err != nil {
-		log.Fatal("Error subscribing to filtered consumer: ", err) // Log an error if subscribing to the filtered consumer fails
+		fatal("Error subscribing to filtered consumer", err) // Log an error if subscribing to the filtered consumer fails
 	}
 
-	fmt.Println("Subscribed to the filtered consumer") // Message about successful subscription
+	logger().Info("Subscribed to the filtered consumer") // Message about successful subscription
 
 	// Fetch messages from the consumer with increased timeout
 	msgs, err := sub.Fetch(5, nats.MaxWait(20*time.Second))
 	if err != nil {
-		log.Fatal("Error fetching messages from filtered consumer: ", err) // Log an error if fetching messages from the filtered consumer fails
+		fatal("Error fetching messages from filtered consumer", err) // Log an error if fetching messages from the filtered consumer fails
 	}
 
 	for _, msg := range msgs {
-		fmt.Printf("Received message from filtered consumer: %s\n", string(msg.Data)) // Print the received message from the filtered consumer
-		msg.Ack()                                                                     // Acknowledge the message
+		logger().Info("Received message from filtered consumer", append(msgAttrs(msg), "data", string(msg.Data))...) // Log the received message from the filtered consumer
+		DefaultMetrics.Ack(msg)                                                                                      // Acknowledge and count the message
 	}
 
-	fmt.Println("Messages from filtered consumer fetched and acknowledged") // Message about successful fetching and acknowledgment of messages from the filtered consumer
+	logger().Info("Messages from filtered consumer fetched and acknowledged") // Message about successful fetching and acknowledgment of messages from the filtered consumer
 }
 
 // Function to demonstrate consumer with ack wait and max deliver settings
@@ -247,29 +575,30 @@ func consumerWithAckWaitAndMaxDeliver(js nats.JetStreamContext) {
 
 	consumerInfo, err := js.AddConsumer("ORDERS", consumerConfig)
 	if err != nil {
-		log.Fatal("Error adding consumer with ack wait and max deliver: ", err) // Log an error if adding the consumer fails
+		fatal("Error adding consumer with ack wait and max deliver", err) // Log an error if adding the consumer fails
 	}
 
-	fmt.Println("Consumer with ack wait and max deliver added: ", consumerInfo.Name) // Message about successful consumer addition
+	logger().Info("Consumer with ack wait and max deliver added", "stream", consumerInfo.Stream, "consumer", consumerInfo.Name) // Message about successful consumer addition
+	DefaultMetrics.WatchConsumer(js, "ORDERS", consumerInfo.Name)                                                               // Report the lag of the consumer
 
 	// Subscribe to the consumer with ack wait and max deliver settings
 	sub, err := js.PullSubscribe("orders.*", "ACK_WAIT_CONSUMER")
 	if err != nil {
-		log.Fatal("Error subscribing to consumer with ack wait and max deliver: ", err) // Log an error if subscribing to the consumer fails
+		fatal("Error subscribing to consumer with ack wait and max deliver", err) // Log an error if subscribing to the consumer fails
 	}
 
-	fmt.Println("Subscribed to the consumer with ack wait and max deliver") // Message about successful subscription
+	logger().Info("Subscribed to the consumer with ack wait and max deliver") // Message about successful subscription
 
 	// Fetch messages from the consumer with increased timeout
 	msgs, err := sub.Fetch(5, nats.MaxWait(20*time.Second))
 	if err != nil {
-		log.Fatal("Error fetching messages from consumer with ack wait and max deliver: ", err) // Log an error if fetching messages fails
+		fatal("Error fetching messages from consumer with ack wait and max deliver", err) // Log an error if fetching messages fails
 	}
 
 	for _, msg := range msgs {
-		fmt.Printf("Received message from consumer with ack wait and max deliver: %s\n", string(msg.Data)) // Print the received message from the consumer
-		msg.Ack()                                                                                          // Acknowledge the message
+		logger().Info("Received message from consumer with ack wait and max deliver", append(msgAttrs(msg), "data", string(msg.Data))...) // Log the received message from the consumer
+		DefaultMetrics.Ack(msg)                                                                                                           // Acknowledge and count the message
 	}
 
-	fmt.Println("Messages from consumer with ack wait and max deliver fetched and acknowledged") // Message about successful fetching and acknowledgment of messages from the consumer
+	logger().Info("Messages from consumer with ack wait and max deliver fetched and acknowledged") // Message about successful fetching and acknowledgment of messages from the consumer
 }
@@ -0,0 +1,220 @@
+package nats_basic
+
+import (
+	"errors" // Import the package for working with errors
+	"fmt"    // Import the package for formatted input/output
+	"time"   // Import the package for working with time
+
+	"github.com/nats-io/nats.go" // Import the package for working with NATS
+)
+
+// Number of revisions kept per key in the example key-value bucket
+const KVHistory = 10
+
+// Default number of attempts made by UpdateKeyWithRetry
+const DefaultUpdateRetries = 5
+
+// Error returned when a read-modify-write keeps conflicting with other writers
+var ErrTooManyConflicts = errors.New("key-value update conflicted too many times")
+
+// Function to check whether an error is a revision mismatch from Create or Update
+func IsRevisionConflict(err error) bool {
+	// Both Create on an existing key and Update with a stale revision fail with the wrong last sequence error
+	return errors.Is(err, nats.ErrKeyExists)
+}
+
+// Function to create a key only if it does not exist yet
+func CreateKey(kv nats.KeyValue, key string, value []byte) (uint64, error) {
+	revision, err := kv.Create(key, value)
+	if err != nil {
+		return 0, fmt.Errorf("create %q: %w", key, err) // Wrap the error with the key for context
+	}
+	return revision, nil
+}
+
+// Function to update a key only if its latest revision matches the expected one
+func UpdateKey(kv nats.KeyValue, key string, value []byte, expected uint64) (uint64, error) {
+	revision, err := kv.Update(key, value, expected)
+	if err != nil {
+		return 0, fmt.Errorf("update %q at revision %d: %w", key, expected, err) // Wrap the error with the key and revision
+	}
+	return revision, nil
+}
+
+// Function to perform a read-modify-write on a key, retrying when another writer wins the race.
+// The modify callback receives nil when the key does not exist (or was deleted).
+func UpdateKeyWithRetry(kv nats.KeyValue, key string, attempts int, modify func(current []byte) ([]byte, error)) (uint64, error) {
+	// Fall back to the default number of attempts
+	if attempts <= 0 {
+		attempts = DefaultUpdateRetries
+	}
+
+	for i := 0; i < attempts; i++ {
+		// Read the current value and its revision
+		var current []byte
+		var revision uint64
+		entry, err := kv.Get(key)
+		switch {
+		case err == nil:
+			current = entry.Value()
+			revision = entry.Revision()
+		case errors.Is(err, nats.ErrKeyNotFound):
+			// The key is missing, so it has to be created
+		default:
+			return 0, fmt.Errorf("get %q: %w", key, err)
+		}
+
+		// Compute the new value
+		next, err := modify(current)
+		if err != nil {
+			return 0, err
+		}
+
+		// Write it back guarded by the revision we read
+		if revision == 0 {
+			revision, err = kv.Create(key, next)
+		} else {
+			revision, err = kv.Update(key, next, revision)
+		}
+		if err == nil {
+			return revision, nil
+		}
+		if !IsRevisionConflict(err) {
+			return 0, fmt.Errorf("write %q: %w", key, err)
+		}
+		// Another writer changed the key in between, so try again
+	}
+
+	return 0, fmt.Errorf("%w: key %q after %d attempts", ErrTooManyConflicts, key, attempts)
+}
+
+// Function to list every stored revision of a key, including delete and purge markers
+func KeyHistory(kv nats.KeyValue, key string) ([]nats.KeyValueEntry, error) {
+	entries, err := kv.History(key)
+	if err != nil {
+		return nil, fmt.Errorf("history %q: %w", key, err)
+	}
+	return entries, nil
+}
+
+// Function to get a short name of a key-value operation
+func OperationName(op nats.KeyValueOp) string {
+	switch op {
+	case nats.KeyValuePut:
+		return "PUT"
+	case nats.KeyValueDelete:
+		return "DEL"
+	case nats.KeyValuePurge:
+		return "PURGE"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Settings used to create a key-value bucket
+type KVBucketOptions struct {
+	Bucket       string           // Bucket name
+	Description  string           // Free-form description of the bucket
+	History      uint8            // Number of revisions kept per key (1-64)
+	TTL          time.Duration    // How long values are kept, zero keeps them forever
+	MaxValueSize int32            // Largest value accepted, zero means unlimited
+	MaxBytes     int64            // Largest total size of the bucket, zero means unlimited
+	Storage      nats.StorageType // File or memory storage
+	Replicas     int              // Number of stream replicas in a cluster
+}
+
+// Function to convert the bucket options into a key-value configuration
+func (o KVBucketOptions) Config() *nats.KeyValueConfig {
+	return &nats.KeyValueConfig{
+		Bucket:       o.Bucket,
+		Description:  o.Description,
+		History:      o.History,
+		TTL:          o.TTL,
+		MaxValueSize: o.MaxValueSize,
+		MaxBytes:     o.MaxBytes,
+		Storage:      o.Storage,
+		Replicas:     o.Replicas,
+	}
+}
+
+// Function to create a key-value bucket from the given options
+func CreateKVBucket(js nats.JetStreamContext, opts KVBucketOptions) (nats.KeyValue, error) {
+	kv, err := js.CreateKeyValue(opts.Config())
+	if err != nil {
+		return nil, fmt.Errorf("create bucket %q: %w", opts.Bucket, err)
+	}
+	return kv, nil
+}
+
+// Function to remove every revision of a key, leaving only a purge marker
+func PurgeKey(kv nats.KeyValue, key string) error {
+	if err := kv.Purge(key); err != nil {
+		return fmt.Errorf("purge %q: %w", key, err)
+	}
+	return nil
+}
+
+// Function to compact tombstones, removing delete and purge markers older than the given age.
+// A negative age removes all markers regardless of how recent they are.
+func CompactDeletes(kv nats.KeyValue, olderThan time.Duration) error {
+	if err := kv.PurgeDeletes(nats.DeleteMarkersOlderThan(olderThan)); err != nil {
+		return fmt.Errorf("purge deletes in %q: %w", kv.Bucket(), err)
+	}
+	return nil
+}
+
+// Status report of a key-value bucket. Like every time.Duration, the TTL is encoded in JSON as
+// integer nanoseconds.
+type KVStatusReport struct {
+	Bucket       string        `json:"bucket"`        // Bucket name
+	Values       uint64        `json:"values"`        // Stored values, including historical revisions and markers
+	Bytes        uint64        `json:"bytes"`         // Size of the bucket in bytes
+	History      int64         `json:"history"`       // Revisions kept per key
+	TTL          time.Duration `json:"ttl"`           // How long values are kept, zero keeps them forever
+	BackingStore string        `json:"backing_store"` // Technology used to store the bucket
+	Stream       string        `json:"stream"`        // Name of the backing stream
+	Compressed   bool          `json:"compressed"`    // Whether the data is compressed on disk
+}
+
+// Function to build a status report of a key-value bucket
+func KVStatus(kv nats.KeyValue) (KVStatusReport, error) {
+	status, err := kv.Status()
+	if err != nil {
+		return KVStatusReport{}, fmt.Errorf("status of %q: %w", kv.Bucket(), err)
+	}
+
+	report := KVStatusReport{
+		Bucket:       status.Bucket(),
+		Values:       status.Values(),
+		Bytes:        status.Bytes(),
+		History:      status.History(),
+		TTL:          status.TTL(),
+		BackingStore: status.BackingStore(),
+		Compressed:   status.IsCompressed(),
+	}
+
+	// The JetStream implementation also exposes the backing stream
+	if bucketStatus, ok := status.(*nats.KeyValueBucketStatus); ok && bucketStatus.StreamInfo() != nil {
+		report.Stream = bucketStatus.StreamInfo().Config.Name
+	}
+	return report, nil
+}
+
+// Function to list the keys matching a subject pattern such as "orders.*" or ">"
+func ListKeys(kv nats.KeyValue, pattern string) ([]string, error) {
+	// Watch only the metadata of the latest revisions and stop after the initial values
+	watcher, err := kv.Watch(pattern, nats.MetaOnly(), nats.IgnoreDeletes())
+	if err != nil {
+		return nil, fmt.Errorf("list keys %q: %w", pattern, err)
+	}
+	defer watcher.Stop() // Stop the watcher when listing completes
+
+	var keys []string
+	for entry := range watcher.Updates() {
+		if entry == nil {
+			break // All current keys were received
+		}
+		keys = append(keys, entry.Key())
+	}
+	return keys, nil
+}
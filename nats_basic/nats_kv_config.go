@@ -0,0 +1,303 @@
+package nats_basic
+
+import (
+	"context"     // Import the package for cancellation
+	"errors"      // Import the package for working with errors
+	"fmt"         // Import the package for formatted input/output
+	"reflect"     // Import the package for inspecting struct fields
+	"sort"        // Import the package for sorting
+	"strconv"     // Import the package for parsing numbers and booleans
+	"sync"        // Import the package for synchronizing goroutines
+	"sync/atomic" // Import the package for atomic pointer swaps
+	"time"        // Import the package for working with time
+
+	"github.com/nats-io/nats.go" // Import the package for working with NATS
+)
+
+// Struct tag naming the key that holds a setting
+const configTag = "kv"
+
+// Kinds of difference between two sets of config values
+const (
+	ConfigAdded   = "added"   // The key only exists in the desired values
+	ConfigRemoved = "removed" // The key only exists in the current values
+	ConfigChanged = "changed" // The key exists in both with different values
+)
+
+// Single difference between two sets of config values
+type ConfigChange struct {
+	Key  string `json:"key"`           // Setting key
+	Kind string `json:"kind"`          // ConfigAdded, ConfigRemoved or ConfigChanged
+	Old  string `json:"old,omitempty"` // Current value
+	New  string `json:"new,omitempty"` // Desired value
+}
+
+// Function to read every setting of a config bucket as strings
+func ReadConfigValues(kv nats.KeyValue) (map[string]string, error) {
+	values := map[string]string{}
+
+	keys, err := kv.Keys()
+	if errors.Is(err, nats.ErrNoKeysFound) {
+		return values, nil // An empty bucket is an empty config
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list config keys: %w", err)
+	}
+
+	for _, key := range keys {
+		entry, err := kv.Get(key)
+		if errors.Is(err, nats.ErrKeyNotFound) {
+			continue // Deleted between listing and reading
+		}
+		if err != nil {
+			return nil, fmt.Errorf("get config key %q: %w", key, err)
+		}
+		values[key] = string(entry.Value())
+	}
+	return values, nil
+}
+
+// Function to write settings into a config bucket
+func WriteConfigValues(kv nats.KeyValue, values map[string]string) error {
+	for key, value := range values {
+		if _, err := kv.PutString(key, value); err != nil {
+			return fmt.Errorf("put config key %q: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// Function to compare current config values with desired ones, sorted by key
+func DiffConfigValues(current, desired map[string]string) []ConfigChange {
+	var changes []ConfigChange
+	for key, newValue := range desired {
+		oldValue, ok := current[key]
+		switch {
+		case !ok:
+			changes = append(changes, ConfigChange{Key: key, Kind: ConfigAdded, New: newValue})
+		case oldValue != newValue:
+			changes = append(changes, ConfigChange{Key: key, Kind: ConfigChanged, Old: oldValue, New: newValue})
+		}
+	}
+	for key, oldValue := range current {
+		if _, ok := desired[key]; !ok {
+			changes = append(changes, ConfigChange{Key: key, Kind: ConfigRemoved, Old: oldValue})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+// Function to decode config values into the fields of a struct tagged with `kv:"key"`.
+// Keys without a matching field are ignored, fields without a key keep their value.
+// Unexported fields cannot be set and are skipped even when tagged.
+func DecodeConfig(values map[string]string, dst any) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("decode config: destination must be a pointer to a struct, got %T", dst)
+	}
+	v = v.Elem()
+
+	for i := 0; i < v.NumField(); i++ {
+		key := v.Type().Field(i).Tag.Get(configTag)
+		if key == "" || !v.Field(i).CanSet() {
+			continue // Not a setting, or an unexported field
+		}
+		raw, ok := values[key]
+		if !ok {
+			continue // Keep the default
+		}
+		if err := setConfigField(v.Field(i), raw); err != nil {
+			return fmt.Errorf("decode config key %q: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// Function to encode the tagged fields of a struct into config values
+func EncodeConfig(src any) (map[string]string, error) {
+	v := reflect.Indirect(reflect.ValueOf(src))
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("encode config: source must be a struct, got %T", src)
+	}
+
+	values := map[string]string{}
+	for i := 0; i < v.NumField(); i++ {
+		key := v.Type().Field(i).Tag.Get(configTag)
+		if key == "" || !v.Type().Field(i).IsExported() {
+			continue // Not a setting, or an unexported field
+		}
+		values[key] = fmt.Sprint(v.Field(i).Interface())
+	}
+	return values, nil
+}
+
+// Function to parse a raw value into a struct field
+func setConfigField(field reflect.Value, raw string) error {
+	// Durations are int64 underneath, so they are handled first
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
+
+// Client keeping a typed, validated snapshot of the settings stored in a config bucket
+type ConfigClient[T any] struct {
+	kv       nats.KeyValue  // Config bucket
+	defaults T              // Values used for keys missing from the bucket
+	validate func(*T) error // Optional validation of a decoded snapshot
+
+	current atomic.Pointer[T] // Latest valid snapshot
+
+	mu       sync.Mutex          // Serialises reloads and protects the callbacks
+	onReload []func(old, new *T) // Called after a new snapshot is swapped in
+	onError  []func(err error)   // Called when a change cannot be applied
+}
+
+// Function to create a config client; validate may be nil
+func NewConfigClient[T any](kv nats.KeyValue, defaults T, validate func(*T) error) *ConfigClient[T] {
+	c := &ConfigClient[T]{kv: kv, defaults: defaults, validate: validate}
+	initial := defaults
+	c.current.Store(&initial) // Start with the defaults until something is loaded
+	return c
+}
+
+// Function to register a callback invoked after every successful reload
+func (c *ConfigClient[T]) OnReload(fn func(old, new *T)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onReload = append(c.onReload, fn)
+}
+
+// Function to register a callback invoked when a change is rejected
+func (c *ConfigClient[T]) OnError(fn func(err error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onError = append(c.onError, fn)
+}
+
+// Function to get the current snapshot; it must be treated as read-only
+func (c *ConfigClient[T]) Current() *T {
+	return c.current.Load()
+}
+
+// Function to load the settings once from the bucket
+func (c *ConfigClient[T]) Load() (*T, error) {
+	values, err := ReadConfigValues(c.kv)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.apply(values); err != nil {
+		return nil, err
+	}
+	return c.Current(), nil
+}
+
+// Function to watch the bucket and reload the settings on every change until the context is cancelled
+func (c *ConfigClient[T]) Watch(ctx context.Context) error {
+	watcher, err := c.kv.WatchAll()
+	if err != nil {
+		return fmt.Errorf("watch config bucket: %w", err)
+	}
+	defer watcher.Stop() // Stop the watcher when watching completes
+
+	values := map[string]string{} // Latest raw values seen by the watcher
+	initialized := false          // Whether the initial values have all been received
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case entry, ok := <-watcher.Updates():
+			if !ok {
+				return nil // The watcher was stopped
+			}
+
+			// A nil entry marks the end of the initial values
+			if entry == nil {
+				initialized = true
+			} else if entry.Operation() == nats.KeyValuePut {
+				values[entry.Key()] = string(entry.Value())
+			} else {
+				delete(values, entry.Key())
+			}
+
+			if !initialized {
+				continue // Apply the initial values all at once
+			}
+			if err := c.apply(values); err != nil {
+				c.reportError(err)
+			}
+		}
+	}
+}
+
+// Function to decode, validate and swap in a new snapshot, then notify the callbacks
+func (c *ConfigClient[T]) apply(values map[string]string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Decode into a copy of the defaults so missing keys fall back to them
+	next := c.defaults
+	if err := DecodeConfig(values, &next); err != nil {
+		return err
+	}
+	if c.validate != nil {
+		if err := c.validate(&next); err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+	}
+
+	// Swap the whole snapshot at once so readers never see a partial update
+	old := c.current.Swap(&next)
+	for _, fn := range c.onReload {
+		fn(old, &next)
+	}
+	return nil
+}
+
+// Function to notify the error callbacks
+func (c *ConfigClient[T]) reportError(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, fn := range c.onError {
+		fn(err)
+	}
+}
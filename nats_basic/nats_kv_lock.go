@@ -0,0 +1,247 @@
+package nats_basic
+
+import (
+	"context" // Import the package for cancellation
+	"errors"  // Import the package for working with errors
+	"fmt"     // Import the package for formatted input/output
+	"sync"    // Import the package for synchronizing goroutines
+	"time"    // Import the package for working with time
+
+	"github.com/nats-io/nats.go" // Import the package for working with NATS
+)
+
+// Errors returned by the key-value lock
+var (
+	ErrLockNotHeld = errors.New("lock is not held")               // Renew or Release called without holding the lock
+	ErrLockLost    = errors.New("lock was lost to another owner") // The lease expired or another owner took the key
+)
+
+// Function to create a bucket for locks whose entries expire after the lease duration.
+// A lock that is not renewed within the lease disappears and can be taken by another owner.
+func CreateLockBucket(js nats.JetStreamContext, bucket string, lease time.Duration) (nats.KeyValue, error) {
+	return CreateKVBucket(js, KVBucketOptions{
+		Bucket:  bucket,           // Lock bucket name
+		History: 1,                // Only the current holder matters
+		TTL:     lease,            // Unrenewed locks expire after the lease
+		Storage: nats.FileStorage, // File storage type
+	})
+}
+
+// Lease-based mutex stored as a single key in a lock bucket
+type KVLock struct {
+	kv    nats.KeyValue // Lock bucket
+	key   string        // Key representing the lock
+	owner string        // Identity written as the value of the key
+
+	mu       sync.Mutex // Protects the revision
+	revision uint64     // Revision of our lock entry, zero when not held
+}
+
+// Function to create a lock on the given key for the given owner
+func NewKVLock(kv nats.KeyValue, key, owner string) *KVLock {
+	return &KVLock{kv: kv, key: key, owner: owner}
+}
+
+// Function to try to take the lock once without waiting
+func (l *KVLock) TryAcquire() (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Create only succeeds when nobody holds the key
+	revision, err := l.kv.Create(l.key, []byte(l.owner))
+	if IsRevisionConflict(err) {
+		return false, nil // Someone else holds the lock
+	}
+	if err != nil {
+		return false, fmt.Errorf("acquire lock %q: %w", l.key, err)
+	}
+
+	l.revision = revision
+	return true, nil
+}
+
+// Function to wait until the lock is taken, polling at the given interval
+func (l *KVLock) Acquire(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		ok, err := l.TryAcquire()
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Function to extend the lease by rewriting the lock entry at the revision we own
+func (l *KVLock) Renew() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.revision == 0 {
+		return ErrLockNotHeld
+	}
+
+	revision, err := l.kv.Update(l.key, []byte(l.owner), l.revision)
+	if IsRevisionConflict(err) {
+		l.revision = 0 // The entry expired or was taken over
+		return fmt.Errorf("%w: %q", ErrLockLost, l.key)
+	}
+	if err != nil {
+		return fmt.Errorf("renew lock %q: %w", l.key, err)
+	}
+
+	l.revision = revision
+	return nil
+}
+
+// Function to give up the lock, only deleting the key if we still own it
+func (l *KVLock) Release() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.revision == 0 {
+		return ErrLockNotHeld
+	}
+
+	revision := l.revision
+	l.revision = 0 // The lock is no longer ours whatever happens below
+
+	err := l.kv.Delete(l.key, nats.LastRevision(revision))
+	if IsRevisionConflict(err) {
+		return fmt.Errorf("%w: %q", ErrLockLost, l.key)
+	}
+	if err != nil {
+		return fmt.Errorf("release lock %q: %w", l.key, err)
+	}
+	return nil
+}
+
+// Function to report whether the lock is believed to be held
+func (l *KVLock) Held() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.revision != 0
+}
+
+// Leader election among several instances competing for the same lock key
+type LeaderElection struct {
+	lock  *KVLock       // Lock whose holder is the leader
+	lease time.Duration // Lease of the lock bucket
+
+	onElected func(ctx context.Context) // Called when leadership is gained, ctx is cancelled when it is lost
+	onDemoted func()                    // Called when leadership is lost
+
+	mu     sync.Mutex // Protects leader
+	leader bool       // Whether this instance is currently the leader
+}
+
+// Function to create a leader election for the given candidate.
+// The lease must match the TTL of the lock bucket; the lock is renewed three times per lease.
+// The callbacks must return promptly, so long-running leader work belongs in a goroutine bound to ctx.
+func NewLeaderElection(kv nats.KeyValue, key, candidate string, lease time.Duration, onElected func(ctx context.Context), onDemoted func()) *LeaderElection {
+	return &LeaderElection{
+		lock:      NewKVLock(kv, key, candidate),
+		lease:     lease,
+		onElected: onElected,
+		onDemoted: onDemoted,
+	}
+}
+
+// Function to report whether this instance is currently the leader
+func (e *LeaderElection) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leader
+}
+
+// Function to campaign for leadership until the context is cancelled.
+// Leadership is released when Run returns.
+func (e *LeaderElection) Run(ctx context.Context) error {
+	if e.lease/3 <= 0 {
+		return fmt.Errorf("lease %s of lock %q is too short to renew", e.lease, e.lock.key)
+	}
+
+	ticker := time.NewTicker(e.lease / 3)
+	defer ticker.Stop()
+
+	cancelTerm := context.CancelFunc(func() {}) // Cancels the context handed to onElected
+	defer func() { cancelTerm() }()
+	var lastRenew time.Time // Time of the last successful acquire or renew
+
+	// Step down, notifying the callbacks
+	demote := func() {
+		e.setLeader(false)
+		cancelTerm()
+		if e.onDemoted != nil {
+			e.onDemoted()
+		}
+	}
+
+	for {
+		if !e.IsLeader() {
+			// Try to become the leader
+			ok, err := e.lock.TryAcquire()
+			if err != nil {
+				logger().Warn("Error campaigning for leadership", "key", e.lock.key, "error", err) // Keep campaigning, the bucket may recover
+			}
+			if err == nil && ok {
+				termCtx, cancel := context.WithCancel(ctx)
+				cancelTerm = cancel
+				lastRenew = time.Now()
+				e.setLeader(true)
+				if e.onElected != nil {
+					e.onElected(termCtx)
+				}
+			}
+		} else {
+			// Keep the lease alive
+			err := e.lock.Renew()
+			switch {
+			case err == nil:
+				lastRenew = time.Now()
+			case errors.Is(err, ErrLockLost):
+				demote() // Another candidate owns the lock, Renew already forgot our revision
+			case time.Since(lastRenew) >= e.lease:
+				// Our lease ran out, forget the lock before stepping down so Held agrees with IsLeader
+				e.release()
+				demote()
+			default:
+				logger().Warn("Error renewing the leadership", "key", e.lock.key, "error", err) // Retry until the lease runs out
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if e.IsLeader() {
+				e.release() // Let another candidate take over right away
+				demote()
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Function to give up the lock, logging why it could not be deleted
+func (e *LeaderElection) release() {
+	if err := e.lock.Release(); err != nil {
+		logger().Warn("Error releasing the leadership", "key", e.lock.key, "error", err) // The key expires with the lease anyway
+	}
+}
+
+// Function to set the leadership flag
+func (e *LeaderElection) setLeader(leader bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.leader = leader
+}
@@ -0,0 +1,56 @@
+package nats_basic
+
+import (
+	"encoding/hex" // Import the package for formatting trace IDs
+	"log/slog"     // Import the package for structured logging
+	"os"           // Import the package for exiting on fatal errors
+	"strings"      // Import the package for recognizing JetStream messages
+	"sync/atomic"  // Import the package for swapping the logger safely
+
+	"github.com/nats-io/nats.go" // Import the package for working with NATS
+)
+
+// Logger set with SetLogger, nil uses slog.Default()
+var currentLogger atomic.Pointer[slog.Logger]
+
+// Function to set the logger used by the package; nil restores slog.Default()
+func SetLogger(l *slog.Logger) {
+	currentLogger.Store(l)
+}
+
+// Function to get the logger used by the package
+func logger() *slog.Logger {
+	if l := currentLogger.Load(); l != nil {
+		return l
+	}
+	return slog.Default()
+}
+
+// Function to log an error and exit the program, used by the examples
+func fatal(msg string, err error, attrs ...any) {
+	logger().Error(msg, append(attrs, slog.Any("error", err))...)
+	os.Exit(1)
+}
+
+// Function to get the log attributes of a message: the subject and trace ID, plus the stream,
+// consumer, sequence and delivery count of a JetStream message
+func msgAttrs(m *nats.Msg) []any {
+	attrs := []any{slog.String("subject", m.Subject)}
+	if tc, ok := ExtractTraceContext(m.Header); ok {
+		attrs = append(attrs, slog.String("trace_id", hex.EncodeToString(tc.TraceID[:])))
+	}
+	if !strings.HasPrefix(m.Reply, "$JS.ACK.") {
+		return attrs
+	}
+
+	meta, err := m.Metadata()
+	if err != nil {
+		return attrs
+	}
+	return append(attrs,
+		slog.String("stream", meta.Stream),
+		slog.String("consumer", meta.Consumer),
+		slog.Uint64("sequence", meta.Sequence.Stream),
+		slog.Uint64("delivery_count", meta.NumDelivered),
+	)
+}
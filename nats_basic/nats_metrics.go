@@ -0,0 +1,476 @@
+package nats_basic
+
+import (
+	"bufio"    // Import the package for buffering the exposition output
+	"errors"   // Import the package for working with errors
+	"fmt"      // Import the package for formatted input/output
+	"io"       // Import the package for writing the exposition format
+	"math"     // Import the package for the +Inf bucket
+	"net/http" // Import the package for serving the metrics endpoint
+	"sort"     // Import the package for ordering families and series
+	"strconv"  // Import the package for formatting sample values
+	"strings"  // Import the package for escaping label values
+	"sync"     // Import the package for protecting the series
+	"time"     // Import the package for measuring handling time
+
+	"github.com/nats-io/nats.go" // Import the package for working with NATS
+)
+
+// Upper bounds in seconds of the default latency histogram buckets
+var DefaultLatencyBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+// Kinds of metric families, named as in the Prometheus exposition format
+const (
+	counterKind   = "counter"
+	gaugeKind     = "gauge"
+	histogramKind = "histogram"
+)
+
+// Values of one labelled series of a family
+type metricSeries struct {
+	labelValues []string // Values of the family labels
+	value       float64  // Counter or gauge value
+	buckets     []uint64 // Observations per histogram bucket, not cumulative
+	sum         float64  // Sum of the histogram observations
+	count       uint64   // Number of histogram observations
+}
+
+// Family of series sharing a name, help text, kind and label names
+type metricFamily struct {
+	name    string    // Metric name
+	help    string    // Help text
+	kind    string    // Counter, gauge or histogram
+	labels  []string  // Label names
+	buckets []float64 // Upper bounds of the histogram buckets
+
+	mu     sync.Mutex               // Protects the series
+	series map[string]*metricSeries // Series keyed by their joined label values
+}
+
+// Function to get the series of the label values, creating it on first use
+func (f *metricFamily) get(labelValues []string) *metricSeries {
+	if len(labelValues) != len(f.labels) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", f.name, len(f.labels), len(labelValues)))
+	}
+
+	key := strings.Join(labelValues, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &metricSeries{labelValues: append([]string(nil), labelValues...)}
+		if f.kind == histogramKind {
+			s.buckets = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// Counter whose series only go up
+type Counter struct{ family *metricFamily }
+
+// Function to add one to the series of the label values
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Function to add a non-negative value to the series of the label values
+func (c *Counter) Add(value float64, labelValues ...string) {
+	c.family.mu.Lock()
+	defer c.family.mu.Unlock()
+	c.family.get(labelValues).value += value
+}
+
+// Gauge whose series go up and down
+type Gauge struct{ family *metricFamily }
+
+// Function to set the series of the label values
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.family.mu.Lock()
+	defer g.family.mu.Unlock()
+	g.family.get(labelValues).value = value
+}
+
+// Function to remove every series of the gauge, so values no longer reported disappear
+func (g *Gauge) Reset() {
+	g.family.mu.Lock()
+	defer g.family.mu.Unlock()
+	g.family.series = map[string]*metricSeries{}
+}
+
+// Histogram counting observations in buckets
+type Histogram struct{ family *metricFamily }
+
+// Function to record an observation in the series of the label values
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.family.mu.Lock()
+	defer h.family.mu.Unlock()
+
+	s := h.family.get(labelValues)
+	for i, bound := range h.family.buckets {
+		if value <= bound {
+			s.buckets[i]++
+			break
+		}
+	}
+	s.sum += value
+	s.count++
+}
+
+// Registry of metric families written in the Prometheus text exposition format
+type MetricsRegistry struct {
+	scrapeMu sync.Mutex // Serializes scrapes, so collectors never run concurrently
+
+	mu         sync.Mutex               // Protects the fields below
+	families   map[string]*metricFamily // Families keyed by name
+	collectors []func()                 // Functions updating metrics at the start of every scrape
+}
+
+// Function to create an empty registry
+func NewMetricsRegistry() *MetricsRegistry {
+	return &MetricsRegistry{families: map[string]*metricFamily{}}
+}
+
+// Function to register a family, panicking on duplicate names like other registration mistakes
+func (r *MetricsRegistry) register(f *metricFamily) *metricFamily {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.families[f.name]; ok {
+		panic("metric " + f.name + " registered twice")
+	}
+	f.series = map[string]*metricSeries{}
+	r.families[f.name] = f
+	return f
+}
+
+// Function to register a counter
+func (r *MetricsRegistry) NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{r.register(&metricFamily{name: name, help: help, kind: counterKind, labels: labels})}
+}
+
+// Function to register a gauge
+func (r *MetricsRegistry) NewGauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{r.register(&metricFamily{name: name, help: help, kind: gaugeKind, labels: labels})}
+}
+
+// Function to register a function updating metrics at the start of every scrape, such as gauges
+// computed from a remote state. It runs once per scrape, before any family is locked.
+func (r *MetricsRegistry) OnScrape(collect func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, collect)
+}
+
+// Function to register a histogram; nil buckets use DefaultLatencyBuckets
+func (r *MetricsRegistry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if buckets == nil {
+		buckets = DefaultLatencyBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &Histogram{r.register(&metricFamily{name: name, help: help, kind: histogramKind, labels: labels, buckets: buckets})}
+}
+
+// Function to write every family in the Prometheus text exposition format
+func (r *MetricsRegistry) WriteTo(w io.Writer) (int64, error) {
+	r.scrapeMu.Lock()
+	defer r.scrapeMu.Unlock()
+
+	r.mu.Lock()
+	families := make([]*metricFamily, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	collectors := append([]func(){}, r.collectors...)
+	r.mu.Unlock()
+
+	for _, collect := range collectors {
+		collect()
+	}
+	sort.Slice(families, func(i, j int) bool { return families[i].name < families[j].name })
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, f := range families {
+		writeFamily(bw, f)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// Function to serve the registry in the Prometheus text exposition format
+func (r *MetricsRegistry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteTo(w)
+	})
+}
+
+// Function to write a family with its help, type and samples
+func writeFamily(w *bufio.Writer, f *metricFamily) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := f.series[key]
+		labels := formatLabels(f.labels, s.labelValues)
+		if f.kind != histogramKind {
+			fmt.Fprintf(w, "%s%s %s\n", f.name, labels, formatFloat(s.value))
+			continue
+		}
+
+		// Histogram buckets are cumulative in the exposition format
+		var cumulative uint64
+		for i, bound := range f.buckets {
+			cumulative += s.buckets[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, withLabel(f.labels, s.labelValues, "le", formatFloat(bound)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, withLabel(f.labels, s.labelValues, "le", formatFloat(math.Inf(1))), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", f.name, labels, formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", f.name, labels, s.count)
+	}
+}
+
+// Function to format a label set such as {subject="orders",queue="workers"}
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + escapeLabel(values[i]) + `"`
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// Function to format a label set with one extra label, such as the le label of a bucket
+func withLabel(names, values []string, name, value string) string {
+	return formatLabels(append(append([]string(nil), names...), name), append(append([]string(nil), values...), value))
+}
+
+// Function to format a sample value, using the +Inf spelling of the exposition format
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Function to escape a label value
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+// Function to escape a help text
+func escapeHelp(v string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(v)
+}
+
+// Writer counting the bytes written through it
+type countingWriter struct {
+	w io.Writer // Underlying writer
+	n int64     // Bytes written
+}
+
+// Function to write and count the bytes
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Metrics of the messaging operations
+type MessagingMetrics struct {
+	registry *MetricsRegistry // Registry the metrics are registered in
+
+	Published       *Counter   // Messages published per subject
+	Delivered       *Counter   // Messages delivered to handlers per subject and queue group
+	HandlerDuration *Histogram // Time spent in message handlers per subject
+	Acks            *Counter   // JetStream acknowledgements per stream, consumer and kind (ack, nak, term)
+	RequestDuration *Histogram // Request-reply latency per subject and outcome
+	ConsumerPending *Gauge     // Messages not yet delivered per watched stream and consumer
+	AckPending      *Gauge     // Messages delivered but not yet acknowledged per watched stream and consumer
+
+	mu        sync.Mutex        // Protects the watched consumers
+	consumers []watchedConsumer // Consumers whose lag is reported
+}
+
+// Consumer whose lag is reported at scrape time
+type watchedConsumer struct {
+	js       nats.JetStreamContext // Context the consumer info is fetched with
+	stream   string                // Stream of the consumer
+	consumer string                // Durable name of the consumer
+}
+
+// Function to register the messaging metrics in a registry
+func NewMessagingMetrics(registry *MetricsRegistry) *MessagingMetrics {
+	m := &MessagingMetrics{
+		registry:        registry,
+		Published:       registry.NewCounter("nats_messages_published_total", "Messages published.", "subject"),
+		Delivered:       registry.NewCounter("nats_messages_delivered_total", "Messages delivered to handlers.", "subject", "queue"),
+		HandlerDuration: registry.NewHistogram("nats_handler_duration_seconds", "Time spent handling a message.", nil, "subject"),
+		Acks:            registry.NewCounter("nats_jetstream_acks_total", "JetStream message acknowledgements.", "stream", "consumer", "kind"),
+		RequestDuration: registry.NewHistogram("nats_request_duration_seconds", "Request-reply latency.", nil, "subject", "outcome"),
+		ConsumerPending: registry.NewGauge("nats_consumer_pending_messages", "Messages of the stream not yet delivered to the consumer.", "stream", "consumer"),
+		AckPending:      registry.NewGauge("nats_consumer_ack_pending_messages", "Messages delivered to the consumer but not yet acknowledged.", "stream", "consumer"),
+	}
+	registry.OnScrape(m.collectConsumers)
+	return m
+}
+
+// Function to get the registry of the metrics
+func (m *MessagingMetrics) Registry() *MetricsRegistry {
+	return m.registry
+}
+
+// Function to publish a message and count it
+func (m *MessagingMetrics) Publish(nc *nats.Conn, subject string, data []byte) error {
+	if err := nc.Publish(subject, data); err != nil {
+		return err
+	}
+	m.Published.Inc(subject)
+	return nil
+}
+
+// Function to wrap a message handler, counting deliveries and measuring the handling time
+func (m *MessagingMetrics) Handler(handler nats.MsgHandler) nats.MsgHandler {
+	return func(msg *nats.Msg) {
+		queue := ""
+		if msg.Sub != nil {
+			queue = msg.Sub.Queue
+		}
+		m.Delivered.Inc(msg.Subject, queue)
+
+		start := time.Now()
+		handler(msg)
+		m.HandlerDuration.Observe(time.Since(start).Seconds(), msg.Subject)
+	}
+}
+
+// Function to acknowledge a JetStream message and count it
+func (m *MessagingMetrics) Ack(msg *nats.Msg) error {
+	return m.acknowledge(msg, "ack", msg.Ack)
+}
+
+// Function to negatively acknowledge a JetStream message, asking for redelivery, and count it
+func (m *MessagingMetrics) Nak(msg *nats.Msg) error {
+	return m.acknowledge(msg, "nak", msg.Nak)
+}
+
+// Function to terminate the delivery of a JetStream message and count it
+func (m *MessagingMetrics) Term(msg *nats.Msg) error {
+	return m.acknowledge(msg, "term", msg.Term)
+}
+
+// Function to send an acknowledgement and count it by stream, consumer and kind
+func (m *MessagingMetrics) acknowledge(msg *nats.Msg, kind string, send func(opts ...nats.AckOpt) error) error {
+	if err := send(); err != nil {
+		return err
+	}
+
+	stream, consumer := "", ""
+	if meta, err := msg.Metadata(); err == nil {
+		stream, consumer = meta.Stream, meta.Consumer
+	}
+	m.Acks.Inc(stream, consumer, kind)
+	return nil
+}
+
+// Function to report the lag of a consumer at every scrape, until the connection of the JetStream
+// context is closed. Watching the same consumer again replaces the context it is fetched with.
+func (m *MessagingMetrics) WatchConsumer(js nats.JetStreamContext, stream, consumer string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	watched := watchedConsumer{js: js, stream: stream, consumer: consumer}
+	for i, c := range m.consumers {
+		if c.stream == stream && c.consumer == consumer {
+			m.consumers[i] = watched
+			return
+		}
+	}
+	m.consumers = append(m.consumers, watched)
+}
+
+// Function to fetch the info of every watched consumer once and set its gauges. Consumers whose
+// connection was closed are no longer watched; other consumers that cannot be fetched are skipped.
+func (m *MessagingMetrics) collectConsumers() {
+	m.mu.Lock()
+	consumers := append([]watchedConsumer(nil), m.consumers...)
+	m.mu.Unlock()
+
+	infos := make([]*nats.ConsumerInfo, 0, len(consumers))
+	var closed []watchedConsumer
+	for _, c := range consumers {
+		info, err := c.js.ConsumerInfo(c.stream, c.consumer)
+		if errors.Is(err, nats.ErrConnectionClosed) {
+			closed = append(closed, c)
+			continue
+		}
+		if err != nil {
+			continue // The consumer was deleted or the server did not answer
+		}
+		infos = append(infos, info)
+	}
+	m.unwatch(closed)
+
+	// Reset the gauges so consumers no longer reported disappear
+	m.ConsumerPending.Reset()
+	m.AckPending.Reset()
+	for _, info := range infos {
+		m.ConsumerPending.Set(float64(info.NumPending), info.Stream, info.Name)
+		m.AckPending.Set(float64(info.NumAckPending), info.Stream, info.Name)
+	}
+}
+
+// Function to stop watching the given consumers
+func (m *MessagingMetrics) unwatch(consumers []watchedConsumer) {
+	if len(consumers) == 0 {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kept := m.consumers[:0]
+	for _, c := range m.consumers {
+		drop := false
+		for _, closed := range consumers {
+			// The context is compared too, the consumer may have been watched again meanwhile
+			if c.js == closed.js && c.stream == closed.stream && c.consumer == closed.consumer {
+				drop = true
+				break
+			}
+		}
+		if !drop {
+			kept = append(kept, c)
+		}
+	}
+	m.consumers = kept
+}
+
+// Metrics shared by the examples, served by main when METRICS_ADDR is set
+var DefaultMetrics = NewMessagingMetrics(NewMetricsRegistry())
+
+// Function to serve the registry on /metrics at the given address until the server is closed
+func ServeMetrics(addr string, registry *MetricsRegistry) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", registry.Handler())
+
+	server := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger().Error("Error serving metrics", "addr", addr, "error", err)
+		}
+	}()
+	return server
+}
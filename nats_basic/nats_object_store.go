@@ -0,0 +1,483 @@
+package nats_basic
+
+import (
+	"context"       // Import the package for cancellation
+	"crypto/sha256" // Import the package for computing SHA-256 digests
+	"errors"        // Import the package for working with errors
+	"fmt"           // Import the package for formatted input/output
+	"hash"          // Import the package for the hash interface
+	"io"            // Import the package for streaming readers and writers
+	"os"            // Import the package for working with files
+	"strconv"       // Import the package for parsing chunk sizes
+	"time"          // Import the package for working with time
+
+	"github.com/nats-io/nats.go" // Import the package for working with NATS
+)
+
+// Default chunk size used for streaming uploads
+const DefaultObjectChunkSize = 128 * 1024
+
+// Layout of the stream backing an object store, as used by nats.go
+const (
+	objectStreamName   = "OBJ_%s"        // Stream of a bucket
+	objectChunkSubject = "$O.%s.C.%s"    // Subject of the chunks of an object, by bucket and object NUID
+	objectChunkTimeout = 5 * time.Second // How long to wait for the next chunk
+)
+
+// Error returned when a downloaded object does not match its stored digest
+var ErrObjectDigestMismatch = errors.New("object digest does not match")
+
+// Settings of a streaming object transfer
+type ObjectTransferOptions struct {
+	ChunkSize uint32                  // Size of the chunks an upload is split into, zero uses DefaultObjectChunkSize
+	Attempts  int                     // Number of attempts before giving up, zero means a single attempt
+	RetryWait time.Duration           // Pause between attempts
+	Progress  func(done, total int64) // Called as bytes are transferred, total is -1 when unknown
+}
+
+// Function to get the number of attempts of a transfer
+func (o ObjectTransferOptions) attempts() int {
+	if o.Attempts <= 0 {
+		return 1
+	}
+	return o.Attempts
+}
+
+// Writer that counts the bytes passing through it and reports progress
+type progressWriter struct {
+	w        io.Writer               // Underlying writer
+	done     int64                   // Bytes written so far
+	total    int64                   // Expected number of bytes, -1 when unknown
+	progress func(done, total int64) // Progress callback, may be nil
+}
+
+// Function to write bytes and report the progress
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.done += int64(n)
+	if p.progress != nil && n > 0 {
+		p.progress(p.done, p.total)
+	}
+	return n, err
+}
+
+// Reader that counts the bytes passing through it and reports progress
+type progressReader struct {
+	r        io.Reader               // Underlying reader
+	done     int64                   // Bytes read so far
+	total    int64                   // Expected number of bytes, -1 when unknown
+	progress func(done, total int64) // Progress callback, may be nil
+}
+
+// Function to read bytes and report the progress
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.done += int64(n)
+	if p.progress != nil && n > 0 {
+		p.progress(p.done, p.total)
+	}
+	return n, err
+}
+
+// Function to stream an object from a reader into the store without loading it into memory.
+// The size is only used for progress reporting and may be -1. Failed uploads are retried
+// from the start when the reader is an io.Seeker; the object only appears once fully written.
+func PutObjectStream(obs nats.ObjectStore, name string, r io.Reader, size int64, opts ObjectTransferOptions) (*nats.ObjectInfo, error) {
+	chunkSize := opts.ChunkSize
+	if chunkSize == 0 {
+		chunkSize = DefaultObjectChunkSize
+	}
+	meta := &nats.ObjectMeta{
+		Name: name,
+		Opts: &nats.ObjectMetaOptions{ChunkSize: chunkSize},
+	}
+
+	seeker, canRetry := r.(io.Seeker)
+	var lastErr error
+	for attempt := 1; attempt <= opts.attempts(); attempt++ {
+		// Rewind the reader before every retry
+		if attempt > 1 {
+			if !canRetry {
+				break
+			}
+			time.Sleep(opts.RetryWait)
+			if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+				return nil, fmt.Errorf("rewind %q for retry: %w", name, err)
+			}
+		}
+
+		info, err := obs.Put(meta, &progressReader{r: r, total: size, progress: opts.Progress})
+		if err == nil {
+			return info, nil
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("put object %q: %w", name, lastErr)
+}
+
+// Function to stream an object from the store into a writer, verifying its SHA-256 digest.
+// When the download fails midway it is resumed from the chunk holding the first missing byte.
+func GetObjectStream(js nats.JetStreamContext, obs nats.ObjectStore, name string, w io.Writer, opts ObjectTransferOptions) (*nats.ObjectInfo, error) {
+	return getObjectFrom(js, obs, name, w, sha256.New(), 0, opts)
+}
+
+// Function to download an object into a file through a temporary ".part" file.
+// An existing ".part" file left by an interrupted download is resumed instead of started over.
+func GetObjectFile(js nats.JetStreamContext, obs nats.ObjectStore, name, path string, opts ObjectTransferOptions) (*nats.ObjectInfo, error) {
+	partPath := path + ".part"
+	part, err := os.OpenFile(partPath, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	defer part.Close() // Close the partial file when the download completes
+
+	// Hash what was already downloaded so the digest covers the whole object
+	h := sha256.New()
+	offset, err := io.Copy(h, part)
+	if err != nil {
+		return nil, fmt.Errorf("read partial download %q: %w", partPath, err)
+	}
+
+	info, err := getObjectFrom(js, obs, name, part, h, offset, opts)
+	if errors.Is(err, ErrObjectDigestMismatch) {
+		os.Remove(partPath) // The partial file is corrupt, so the next attempt starts over
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := part.Close(); err != nil {
+		return nil, err
+	}
+	return info, os.Rename(partPath, path)
+}
+
+// Function to download an object starting at the given offset, with h already covering the skipped bytes.
+// A link is followed to its target, which is what gets downloaded and returned.
+func getObjectFrom(js nats.JetStreamContext, obs nats.ObjectStore, name string, w io.Writer, h hash.Hash, offset int64, opts ObjectTransferOptions) (*nats.ObjectInfo, error) {
+	info, bucket, err := resolveObject(js, obs, name)
+	if err != nil {
+		return nil, err
+	}
+
+	// A partial download larger than the object belongs to something else
+	if offset > int64(info.Size) {
+		return nil, fmt.Errorf("%w: %q is %d bytes, already have %d", ErrObjectDigestMismatch, name, info.Size, offset)
+	}
+
+	// Everything written is also hashed and counted for progress
+	out := &progressWriter{w: io.MultiWriter(w, h), done: offset, total: int64(info.Size), progress: opts.Progress}
+
+	var lastErr error
+	for attempt := 1; attempt <= opts.attempts(); attempt++ {
+		if attempt > 1 {
+			time.Sleep(opts.RetryWait)
+		}
+
+		lastErr = copyObjectFrom(js, bucket, info, out, out.done)
+		if lastErr == nil {
+			break
+		}
+	}
+	if lastErr != nil {
+		return nil, fmt.Errorf("get object %q after %d bytes: %w", name, out.done, lastErr)
+	}
+
+	// Compare the digest of what we received with the stored one
+	if digest := nats.GetObjectDigestValue(h); digest != info.Digest {
+		return nil, fmt.Errorf("%w: %q has %s, received %s", ErrObjectDigestMismatch, name, info.Digest, digest)
+	}
+	return info, nil
+}
+
+// Function to get the information about an object and the bucket holding its chunks. Links are followed
+// like obs.Get does, also into other buckets; a link to a whole bucket has no content to download.
+func resolveObject(js nats.JetStreamContext, obs nats.ObjectStore, name string) (*nats.ObjectInfo, string, error) {
+	info, err := obs.GetInfo(name)
+	if err != nil {
+		return nil, "", fmt.Errorf("get object info %q: %w", name, err)
+	}
+	status, err := obs.Status()
+	if err != nil {
+		return nil, "", fmt.Errorf("get object store status: %w", err)
+	}
+	if info.Opts == nil || info.Opts.Link == nil {
+		return info, status.Bucket(), nil
+	}
+
+	link := info.Opts.Link
+	if link.Name == "" {
+		return nil, "", fmt.Errorf("get object %q: %w", name, nats.ErrCantGetBucket)
+	}
+	if link.Bucket == status.Bucket() {
+		return resolveObject(js, obs, link.Name)
+	}
+	target, err := js.ObjectStore(link.Bucket)
+	if err != nil {
+		return nil, "", fmt.Errorf("open object store %q linked by %q: %w", link.Bucket, name, err)
+	}
+	return resolveObject(js, target, link.Name)
+}
+
+// Function to copy an object into a writer starting at the given offset. The chunks are read from the
+// backing stream directly, so a resumed download only transfers the chunks from the offset on.
+func copyObjectFrom(js nats.JetStreamContext, bucket string, info *nats.ObjectInfo, w io.Writer, offset int64) error {
+	stream := fmt.Sprintf(objectStreamName, bucket)
+	subject := fmt.Sprintf(objectChunkSubject, bucket, info.NUID)
+	remaining := int64(info.Size) - offset
+	if remaining <= 0 {
+		return nil // Everything was delivered already
+	}
+
+	// Start at the first chunk, or at the chunk holding the first missing byte
+	start, skip := nats.DeliverAll(), int64(0)
+	if offset > 0 {
+		seq, chunkSkip, err := findObjectChunk(js, stream, subject, offset)
+		if err != nil {
+			return err
+		}
+		start, skip = nats.StartSequence(seq), chunkSkip
+	}
+
+	sub, err := js.SubscribeSync(subject, nats.BindStream(stream), nats.OrderedConsumer(), start)
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe() // Remove the consumer when the copy completes
+
+	for remaining > 0 {
+		m, err := sub.NextMsg(objectChunkTimeout)
+		if err != nil {
+			return err
+		}
+
+		// Drop the start of the first chunk, which was delivered before the offset
+		data := m.Data
+		if skip > 0 {
+			data = data[min(skip, int64(len(data))):]
+			skip = 0
+		}
+		if int64(len(data)) > remaining {
+			data = data[:remaining]
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		remaining -= int64(len(data))
+	}
+	return nil
+}
+
+// Function to find the stream sequence of the chunk holding the byte at the given offset and the
+// position of that byte in the chunk. Only the chunk headers are delivered, not their data.
+func findObjectChunk(js nats.JetStreamContext, stream, subject string, offset int64) (uint64, int64, error) {
+	sub, err := js.SubscribeSync(subject, nats.BindStream(stream), nats.OrderedConsumer(), nats.HeadersOnly())
+	if err != nil {
+		return 0, 0, err
+	}
+	defer sub.Unsubscribe() // Remove the consumer once the chunk is found
+
+	var position int64 // Offset of the first byte of the current chunk
+	for {
+		m, err := sub.NextMsg(objectChunkTimeout)
+		if err != nil {
+			return 0, 0, fmt.Errorf("find chunk at offset %d: %w", offset, err)
+		}
+		size, err := strconv.ParseInt(m.Header.Get(nats.MsgSize), 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("read size of chunk: %w", err)
+		}
+
+		if position+size > offset {
+			meta, err := m.Metadata()
+			if err != nil {
+				return 0, 0, err
+			}
+			return meta.Sequence.Stream, offset - position, nil
+		}
+		position += size
+	}
+}
+
+// Function to upload a file to the store under the given name
+func PutObjectFile(obs nats.ObjectStore, name, path string, opts ObjectTransferOptions) (*nats.ObjectInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() // Close the file when the upload completes
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return PutObjectStream(obs, name, f, stat.Size(), opts)
+}
+
+// Kinds of object store change reported by WatchObjects
+const (
+	ObjectAdded   = "added"   // An object was put or its metadata was updated
+	ObjectDeleted = "deleted" // An object was deleted
+)
+
+// Summary of an object used for listing
+type ObjectSummary struct {
+	Name        string            `json:"name"`                  // Object name
+	Size        uint64            `json:"size"`                  // Size in bytes
+	Modified    time.Time         `json:"modified"`              // Time of the last change
+	Digest      string            `json:"digest,omitempty"`      // SHA-256 digest of the content
+	Chunks      uint32            `json:"chunks"`                // Number of chunks the object is stored in
+	Description string            `json:"description,omitempty"` // Free-form description
+	Headers     map[string]string `json:"headers,omitempty"`     // Custom metadata headers
+	Link        string            `json:"link,omitempty"`        // Target of a link, empty for regular objects
+}
+
+// Change to an object store reported by WatchObjects
+type ObjectEvent struct {
+	Kind string           // ObjectAdded or ObjectDeleted
+	Info *nats.ObjectInfo // Information about the object
+}
+
+// Function to summarise the information about an object
+func SummarizeObject(info *nats.ObjectInfo) ObjectSummary {
+	summary := ObjectSummary{
+		Name:        info.Name,
+		Size:        info.Size,
+		Modified:    info.ModTime,
+		Digest:      info.Digest,
+		Chunks:      info.Chunks,
+		Description: info.Description,
+	}
+
+	// Keep the first value of every header
+	if len(info.Headers) > 0 {
+		summary.Headers = map[string]string{}
+		for key := range info.Headers {
+			summary.Headers[key] = info.Headers.Get(key)
+		}
+	}
+
+	// Describe the target of links as bucket/name or bucket/
+	if info.Opts != nil && info.Opts.Link != nil {
+		summary.Link = info.Opts.Link.Bucket + "/" + info.Opts.Link.Name
+	}
+	return summary
+}
+
+// Function to list the objects in the store
+func ListObjects(obs nats.ObjectStore) ([]ObjectSummary, error) {
+	infos, err := obs.List()
+	if errors.Is(err, nats.ErrNoObjectsFound) {
+		return nil, nil // An empty store has no objects
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list objects: %w", err)
+	}
+
+	summaries := make([]ObjectSummary, 0, len(infos))
+	for _, info := range infos {
+		summaries = append(summaries, SummarizeObject(info))
+	}
+	return summaries, nil
+}
+
+// Function to set the description of an object and merge custom headers into its metadata
+func UpdateObjectMeta(obs nats.ObjectStore, name, description string, headers map[string]string) error {
+	info, err := obs.GetInfo(name)
+	if err != nil {
+		return fmt.Errorf("get object info %q: %w", name, err)
+	}
+
+	// Start from the current metadata so existing headers are kept
+	meta := info.ObjectMeta
+	meta.Description = description
+	if meta.Headers == nil {
+		meta.Headers = nats.Header{}
+	}
+	for key, value := range headers {
+		meta.Headers.Set(key, value)
+	}
+
+	if err := obs.UpdateMeta(name, &meta); err != nil {
+		return fmt.Errorf("update meta of %q: %w", name, err)
+	}
+	return nil
+}
+
+// Function to add a link pointing to another object in the same store
+func LinkObject(obs nats.ObjectStore, linkName, target string) (*nats.ObjectInfo, error) {
+	info, err := obs.GetInfo(target)
+	if err != nil {
+		return nil, fmt.Errorf("get object info %q: %w", target, err)
+	}
+
+	link, err := obs.AddLink(linkName, info)
+	if err != nil {
+		return nil, fmt.Errorf("link %q to %q: %w", linkName, target, err)
+	}
+	return link, nil
+}
+
+// Function to add a link pointing to another object store
+func LinkBucket(obs nats.ObjectStore, linkName string, bucket nats.ObjectStore) (*nats.ObjectInfo, error) {
+	link, err := obs.AddBucketLink(linkName, bucket)
+	if err != nil {
+		return nil, fmt.Errorf("link %q to bucket: %w", linkName, err)
+	}
+	return link, nil
+}
+
+// Function to call onEvent for every object added to or deleted from the store until the context is cancelled
+func WatchObjects(ctx context.Context, obs nats.ObjectStore, onEvent func(ObjectEvent)) error {
+	watcher, err := obs.Watch(nats.UpdatesOnly())
+	if err != nil {
+		return fmt.Errorf("watch object store: %w", err)
+	}
+	defer watcher.Stop() // Stop the watcher when watching completes
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case info, ok := <-watcher.Updates():
+			if !ok {
+				return nil // The watcher was stopped
+			}
+			if info == nil {
+				continue // End of the initial values, which are skipped
+			}
+
+			kind := ObjectAdded
+			if info.Deleted {
+				kind = ObjectDeleted
+			}
+			onEvent(ObjectEvent{Kind: kind, Info: info})
+		}
+	}
+}
+
+// Settings used to create an object store bucket
+type ObjectBucketOptions struct {
+	Bucket      string           // Bucket name
+	Description string           // Free-form description of the bucket
+	TTL         time.Duration    // How long objects are kept, zero keeps them forever
+	MaxBytes    int64            // Largest total size of the bucket, zero means unlimited
+	Storage     nats.StorageType // File or memory storage
+	Replicas    int              // Number of stream replicas in a cluster
+}
+
+// Function to create an object store bucket from the given options
+func CreateObjectBucket(js nats.JetStreamContext, opts ObjectBucketOptions) (nats.ObjectStore, error) {
+	obs, err := js.CreateObjectStore(&nats.ObjectStoreConfig{
+		Bucket:      opts.Bucket,
+		Description: opts.Description,
+		TTL:         opts.TTL,
+		MaxBytes:    opts.MaxBytes,
+		Storage:     opts.Storage,
+		Replicas:    opts.Replicas,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create object bucket %q: %w", opts.Bucket, err)
+	}
+	return obs, nil
+}
@@ -1,8 +1,6 @@
 package nats_basic
 
 import (
-	"fmt"  // Import the package for formatted input/output
-	"log"  // Import the package for logging errors
 	"time" // Import the package for working with time
 
 	"github.com/nats-io/nats.go" // Import the package for working with NATS
@@ -10,39 +8,47 @@ import (
 
 // Function to setup a NATS publisher and subscriber
 func PubSubExample() {
-	// Print a message about launching the Pub-Sub example
-	fmt.Println("\n--- Example of using Pub-Sub NATS ---")
+	// Log a message about launching the Pub-Sub example
+	logger().Info("Example of using Pub-Sub NATS")
 
-	// Connect to NATS server
-	nc, err := nats.Connect(nats.DefaultURL)
-	if err != nil {
-		log.Fatal(err) // Log an error if the connection fails
-	}
-	defer nc.Close() // Close the connection when the function completes
+	// Connect to NATS server, reporting slow consumers
+	nc, closeConn := connectExample("pub-sub-example", nats.ErrorHandler(SlowConsumerHandler(logSlowConsumer)))
+	defer closeConn() // Drain and close the connection when the function completes
 
-	fmt.Println("Connected to NATS server") // Message about successful connection
+	logger().Info("Connected to NATS server") // Message about successful connection
 
 	// Setup a subscriber to receive messages
-	_, err = nc.Subscribe("updates", func(m *nats.Msg) {
-		fmt.Printf("Received message: %s\n", string(m.Data)) // Print the received message
-	})
+	sub, err := nc.Subscribe("updates", DefaultMetrics.Handler(func(m *nats.Msg) {
+		logger().Info("Received message", append(msgAttrs(m), "data", string(m.Data))...) // Log the received message
+	}))
 	if err != nil {
-		log.Fatal(err) // Log an error if setting up the subscriber fails
+		fatal("Error setting up the subscriber", err) // Log an error if setting up the subscriber fails
+	}
+
+	// Limit the messages buffered by the subscriber
+	if err := ApplyPendingLimits(sub, PendingLimits{Msgs: 1000, Bytes: 1024 * 1024}); err != nil {
+		fatal("Error setting the limits", err) // Log an error if setting the limits fails
 	}
 
-	fmt.Println("Subscriber set up") // Message about successful subscriber setup
+	logger().Info("Subscriber set up") // Message about successful subscriber setup
 
 	// Allow some time for the subscriber to set up
 	time.Sleep(1 * time.Second)
 
 	// Publish a message
-	err = nc.Publish("updates", []byte("Hello, World!"))
+	err = DefaultMetrics.Publish(nc, "updates", []byte("Hello, World!"))
 	if err != nil {
-		log.Fatal(err) // Log an error if publishing the message fails
+		fatal("Error publishing the message", err) // Log an error if publishing the message fails
 	}
 
-	fmt.Println("Message published: Hello, World!") // Message about successful publication
+	logger().Info("Message published: Hello, World!") // Message about successful publication
 
 	// Allow some time for the subscriber to receive the message
 	time.Sleep(1 * time.Second)
+
+	stats, err := SnapshotSubscription(sub)
+	if err != nil {
+		fatal("Error reading the statistics", err) // Log an error if reading the statistics fails
+	}
+	logger().Info("Subscription statistics", "stats", stats) // Log the delivery statistics
 }
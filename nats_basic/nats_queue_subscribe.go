@@ -2,7 +2,6 @@ package nats_basic
 
 import (
 	"fmt"  // Import the package for formatted input/output
-	"log"  // Import the package for logging errors
 	"sync" // Import the package for synchronizing goroutines
 	"time" // Import the package for working with time
 
@@ -11,45 +10,56 @@ import (
 
 // Function to setup NATS queue subscribers
 func QueueSubscribeExample() {
-	// Print a message about launching the Queue Subscribe example
-	fmt.Println("\n--- Example of using Queue Subscribe NATS ---")
+	// Log a message about launching the Queue Subscribe example
+	logger().Info("Example of using Queue Subscribe NATS")
 
-	// Connect to NATS server
-	nc, err := nats.Connect(nats.DefaultURL)
-	if err != nil {
-		log.Fatal(err) // Log an error if the connection fails
-	}
-	defer nc.Close() // Close the connection when the function completes
+	// Connect to NATS server, reporting slow consumers
+	nc, closeConn := connectExample("queue-subscribe-example", nats.ErrorHandler(SlowConsumerHandler(logSlowConsumer)))
+	defer closeConn() // Drain and close the connection when the function completes
 
-	fmt.Println("Connected to NATS server") // Message about successful connection
+	logger().Info("Connected to NATS server") // Message about successful connection
 
 	// Create a WaitGroup variable to wait for all subscribers to complete
 	var wg sync.WaitGroup
 
+	// Subscriptions of the workers, each set by its own goroutine
+	var subs [2]*nats.Subscription
+
+	// Limit the messages buffered by each worker
+	limits := PendingLimits{Msgs: 100, Bytes: 64 * 1024}
+
 	// Setup the first queue subscriber
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		_, err := nc.QueueSubscribe("tasks", "worker", func(m *nats.Msg) {
-			fmt.Printf("Worker 1 received: %s\n", string(m.Data)) // Print the received message
-		})
+		sub, err := nc.QueueSubscribe("tasks", "worker", DefaultMetrics.Handler(func(m *nats.Msg) {
+			logger().Info("Worker received message", append(msgAttrs(m), "worker", 1, "data", string(m.Data))...) // Log the received message
+		}))
 		if err != nil {
-			log.Fatal(err) // Log an error if setting up the subscriber fails
+			fatal("Error setting up the subscriber", err) // Log an error if setting up the subscriber fails
 		}
-		fmt.Println("Worker 1 subscribed to queue") // Message about successful subscription
+		if err := ApplyPendingLimits(sub, limits); err != nil {
+			fatal("Error setting the limits", err) // Log an error if setting the limits fails
+		}
+		subs[0] = sub
+		logger().Info("Worker 1 subscribed to queue") // Message about successful subscription
 	}()
 
 	// Setup the second queue subscriber
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		_, err := nc.QueueSubscribe("tasks", "worker", func(m *nats.Msg) {
-			fmt.Printf("Worker 2 received: %s\n", string(m.Data)) // Print the received message
-		})
+		sub, err := nc.QueueSubscribe("tasks", "worker", DefaultMetrics.Handler(func(m *nats.Msg) {
+			logger().Info("Worker received message", append(msgAttrs(m), "worker", 2, "data", string(m.Data))...) // Log the received message
+		}))
 		if err != nil {
-			log.Fatal(err) // Log an error if setting up the subscriber fails
+			fatal("Error setting up the subscriber", err) // Log an error if setting up the subscriber fails
+		}
+		if err := ApplyPendingLimits(sub, limits); err != nil {
+			fatal("Error setting the limits", err) // Log an error if setting the limits fails
 		}
-		fmt.Println("Worker 2 subscribed to queue") // Message about successful subscription
+		subs[1] = sub
+		logger().Info("Worker 2 subscribed to queue") // Message about successful subscription
 	}()
 
 	// Allow some time for the subscribers to set up
@@ -57,11 +67,11 @@ func QueueSubscribeExample() {
 
 	// Publish messages
 	for i := 1; i <= 5; i++ {
-		err := nc.Publish("tasks", []byte(fmt.Sprintf("Task %d", i)))
+		err := DefaultMetrics.Publish(nc, "tasks", []byte(fmt.Sprintf("Task %d", i)))
 		if err != nil {
-			log.Fatal(err) // Log an error if publishing the message fails
+			fatal("Error publishing the message", err) // Log an error if publishing the message fails
 		}
-		fmt.Printf("Published message: Task %d\n", i) // Message about successful publication
+		logger().Info("Published message", "subject", "tasks", "task", i) // Message about successful publication
 	}
 
 	// Allow some time for the subscribers to receive the messages
@@ -70,5 +80,14 @@ func QueueSubscribeExample() {
 	// Wait for all subscribers to complete
 	wg.Wait()
 
-	fmt.Println("All messages received and processed") // Message about successful processing of all messages
+	// Log the delivery statistics of every worker
+	for _, sub := range subs {
+		stats, err := SnapshotSubscription(sub)
+		if err != nil {
+			fatal("Error reading the statistics", err) // Log an error if reading the statistics fails
+		}
+		logger().Info("Subscription statistics", "stats", stats) // Log the delivery statistics
+	}
+
+	logger().Info("All messages received and processed") // Message about successful processing of all messages
 }
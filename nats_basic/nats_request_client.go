@@ -0,0 +1,301 @@
+package nats_basic
+
+import (
+	"context"   // Import the package for cancellation and deadlines
+	"errors"    // Import the package for working with errors
+	"fmt"       // Import the package for formatted input/output
+	"math/rand" // Import the package for backoff jitter
+	"sort"      // Import the package for computing percentiles
+	"sync"      // Import the package for synchronizing goroutines
+	"time"      // Import the package for working with time
+
+	"github.com/nats-io/nats.go" // Import the package for working with NATS
+)
+
+// Number of latency samples kept per subject for percentiles
+const latencySamples = 1024
+
+// Error returned when a request got no reply before its deadline
+var ErrRequestTimeout = errors.New("request timed out")
+
+// Settings of a request client
+type RequestClientOptions struct {
+	AttemptTimeout time.Duration     // Deadline of a single attempt, zero relies on the context deadline only
+	Attempts       int               // Attempts made by idempotent requests, zero means a single attempt
+	BaseBackoff    time.Duration     // Pause before the first retry, doubled for every further retry
+	MaxBackoff     time.Duration     // Largest pause between retries
+	HedgeDelay     time.Duration     // Send a second copy of an idempotent request if no reply came within this delay, zero disables hedging
+	Metrics        *MessagingMetrics // Optional metrics recording the latency and outcome of every attempt
+	Tracer         *Tracer           // Optional tracer recording a client span per attempt and propagating the trace context
+}
+
+// Latency and outcome statistics of the requests sent to a subject
+type RequestStats struct {
+	Requests     int           `json:"requests"`      // Attempts sent, including retries and hedges
+	Successes    int           `json:"successes"`     // Attempts answered with a reply
+	Timeouts     int           `json:"timeouts"`      // Attempts without a reply before the deadline
+	NoResponders int           `json:"no_responders"` // Attempts nobody was listening to
+	Errors       int           `json:"errors"`        // Attempts failing for another reason
+	Retries      int           `json:"retries"`       // Retries after a failed attempt
+	Hedges       int           `json:"hedges"`        // Hedged copies sent
+	Min          time.Duration `json:"min"`           // Fastest successful attempt
+	Max          time.Duration `json:"max"`           // Slowest successful attempt
+	Mean         time.Duration `json:"mean"`          // Mean latency of successful attempts
+	P50          time.Duration `json:"p50"`           // Median latency of recent successful attempts
+	P99          time.Duration `json:"p99"`           // 99th percentile latency of recent successful attempts
+}
+
+// Running statistics of a subject
+type subjectStats struct {
+	stats   RequestStats    // Counters and min/max
+	total   time.Duration   // Sum of successful latencies for the mean
+	samples []time.Duration // Ring buffer of recent successful latencies
+	next    int             // Next position in the ring buffer
+}
+
+// Client sending requests with deadlines, retries, hedging and latency statistics
+type RequestClient struct {
+	nc   *nats.Conn           // Connection the requests are sent on
+	opts RequestClientOptions // Client settings
+
+	mu    sync.Mutex               // Protects the statistics
+	stats map[string]*subjectStats // Statistics keyed by subject
+}
+
+// Function to create a request client
+func NewRequestClient(nc *nats.Conn, opts RequestClientOptions) *RequestClient {
+	return &RequestClient{nc: nc, opts: opts, stats: map[string]*subjectStats{}}
+}
+
+// Function to send a request once. The error wraps nats.ErrNoResponders when nobody listens
+// and ErrRequestTimeout when no reply arrived before the deadline, together with its cause such as
+// context.DeadlineExceeded.
+func (c *RequestClient) Request(ctx context.Context, subject string, data []byte) (*nats.Msg, error) {
+	return c.attempt(ctx, subject, data)
+}
+
+// Function to send a request that is safe to repeat, retrying with jittered exponential backoff
+// on timeouts and missing responders, and hedging slow attempts when configured
+func (c *RequestClient) RequestIdempotent(ctx context.Context, subject string, data []byte) (*nats.Msg, error) {
+	attempts := c.opts.Attempts
+	if attempts <= 0 {
+		attempts = 1
+	}
+
+	var lastErr error
+	for i := 0; i < attempts; i++ {
+		// Wait before every retry, unless the caller gives up first
+		if i > 0 {
+			c.record(subject, func(s *subjectStats) { s.stats.Retries++ })
+			select {
+			case <-ctx.Done():
+				return nil, fmt.Errorf("request to %s: %w (last error: %v)", subject, ctx.Err(), lastErr)
+			case <-time.After(c.backoff(i)):
+			}
+		}
+
+		msg, err := c.hedged(ctx, subject, data)
+		if err == nil {
+			return msg, nil
+		}
+		lastErr = err
+
+		// Only timeouts and missing responders are worth retrying
+		if !errors.Is(err, ErrRequestTimeout) && !errors.Is(err, nats.ErrNoResponders) {
+			return nil, err
+		}
+	}
+	return nil, lastErr
+}
+
+// Function to get a snapshot of the statistics of every subject
+func (c *RequestClient) Stats() map[string]RequestStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	snapshot := make(map[string]RequestStats, len(c.stats))
+	for subject, s := range c.stats {
+		stats := s.stats
+		if stats.Successes > 0 {
+			stats.Mean = s.total / time.Duration(stats.Successes)
+		}
+
+		// Compute the percentiles over the recent samples
+		sorted := append([]time.Duration(nil), s.samples...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		if len(sorted) > 0 {
+			stats.P50 = sorted[len(sorted)*50/100]
+			stats.P99 = sorted[len(sorted)*99/100]
+		}
+		snapshot[subject] = stats
+	}
+	return snapshot
+}
+
+// Function to compute the jittered pause before the given retry
+func (c *RequestClient) backoff(retry int) time.Duration {
+	base := c.opts.BaseBackoff
+	if base <= 0 {
+		return 0
+	}
+
+	// Double the pause for every retry, up to the maximum
+	d := base << (retry - 1)
+	if c.opts.MaxBackoff > 0 && (d > c.opts.MaxBackoff || d <= 0) {
+		d = c.opts.MaxBackoff
+	}
+
+	// Full jitter spreads retries of many clients apart
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
+
+// Function to send an attempt, and a second copy if the first is slower than the hedge delay.
+// The first reply wins and the other attempt is cancelled.
+func (c *RequestClient) hedged(ctx context.Context, subject string, data []byte) (*nats.Msg, error) {
+	if c.opts.HedgeDelay <= 0 {
+		return c.attempt(ctx, subject, data)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // Cancel the losing attempt
+
+	type result struct {
+		msg *nats.Msg
+		err error
+	}
+	results := make(chan result, 2)
+	send := func() {
+		msg, err := c.attempt(ctx, subject, data)
+		results <- result{msg, err}
+	}
+
+	go send()
+	inflight := 1
+	hedge := time.NewTimer(c.opts.HedgeDelay)
+	defer hedge.Stop()
+
+	var lastErr error
+	for {
+		select {
+		case <-hedge.C:
+			// The first attempt is slow, so race it with a copy
+			c.record(subject, func(s *subjectStats) { s.stats.Hedges++ })
+			go send()
+			inflight++
+		case r := <-results:
+			inflight--
+			if r.err == nil {
+				return r.msg, nil
+			}
+			lastErr = r.err
+			if inflight == 0 {
+				return nil, lastErr
+			}
+		}
+	}
+}
+
+// Function to send a single attempt and record its outcome
+func (c *RequestClient) attempt(ctx context.Context, subject string, data []byte) (*nats.Msg, error) {
+	if c.opts.AttemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.opts.AttemptTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	msg, err := c.send(ctx, subject, data)
+	latency := time.Since(start)
+
+	if c.opts.Metrics != nil {
+		c.opts.Metrics.RequestDuration.Observe(latency.Seconds(), subject, requestOutcome(err))
+	}
+
+	switch {
+	case err == nil:
+		c.record(subject, func(s *subjectStats) {
+			s.stats.Requests++
+			s.stats.Successes++
+			s.addSample(latency)
+		})
+		return msg, nil
+	case errors.Is(err, nats.ErrNoResponders):
+		c.record(subject, func(s *subjectStats) {
+			s.stats.Requests++
+			s.stats.NoResponders++
+		})
+		return nil, fmt.Errorf("request to %s: %w", subject, err)
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, nats.ErrTimeout):
+		c.record(subject, func(s *subjectStats) {
+			s.stats.Requests++
+			s.stats.Timeouts++
+		})
+		return nil, fmt.Errorf("request to %s after %s: %w: %w", subject, latency.Round(time.Millisecond), ErrRequestTimeout, err) // Keep the cause, e.g. context.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		// Cancelled by the caller or because a hedged copy won, so not counted as a failure
+		c.record(subject, func(s *subjectStats) { s.stats.Requests++ })
+		return nil, fmt.Errorf("request to %s: %w", subject, err)
+	default:
+		c.record(subject, func(s *subjectStats) {
+			s.stats.Requests++
+			s.stats.Errors++
+		})
+		return nil, fmt.Errorf("request to %s: %w", subject, err)
+	}
+}
+
+// Function to send the request of an attempt, in a traced span when a tracer is set
+func (c *RequestClient) send(ctx context.Context, subject string, data []byte) (*nats.Msg, error) {
+	if c.opts.Tracer == nil {
+		return c.nc.RequestWithContext(ctx, subject, data)
+	}
+	return c.opts.Tracer.Request(ctx, c.nc, subject, data)
+}
+
+// Function to name the outcome of an attempt for the metrics
+func requestOutcome(err error) string {
+	switch {
+	case err == nil:
+		return "success"
+	case errors.Is(err, nats.ErrNoResponders):
+		return "no_responders"
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, nats.ErrTimeout):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	default:
+		return "error"
+	}
+}
+
+// Function to update the statistics of a subject under the lock
+func (c *RequestClient) record(subject string, update func(s *subjectStats)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.stats[subject]
+	if !ok {
+		s = &subjectStats{}
+		c.stats[subject] = s
+	}
+	update(s)
+}
+
+// Function to add a successful latency to the statistics
+func (s *subjectStats) addSample(latency time.Duration) {
+	if s.stats.Successes == 1 || latency < s.stats.Min {
+		s.stats.Min = latency
+	}
+	if latency > s.stats.Max {
+		s.stats.Max = latency
+	}
+	s.total += latency
+
+	// Overwrite the oldest sample once the ring buffer is full
+	if len(s.samples) < latencySamples {
+		s.samples = append(s.samples, latency)
+	} else {
+		s.samples[s.next] = latency
+		s.next = (s.next + 1) % latencySamples
+	}
+}
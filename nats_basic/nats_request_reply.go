@@ -1,46 +1,73 @@
 package nats_basic
 
 import (
-	"fmt"  // Import the package for formatted input/output
-	"log"  // Import the package for logging errors
-	"time" // Import the package for working with time
+	"context" // Import the package for cancellation and deadlines
+	"errors"  // Import the package for working with errors
+	"time"    // Import the package for working with time
 
 	"github.com/nats-io/nats.go" // Import the package for working with NATS
 )
 
 // Function to setup a NATS server connection and perform request-reply
 func RequestReplyExample() {
-	// Print a message about launching the Request-Reply example
-	fmt.Println("\n--- Example of using Request-Reply NATS ---")
+	// Log a message about launching the Request-Reply example
+	logger().Info("Example of using Request-Reply NATS")
 
 	// Connect to NATS server
-	nc, err := nats.Connect(nats.DefaultURL)
-	if err != nil {
-		log.Fatal(err) // Log an error if the connection fails
-	}
-	defer nc.Close() // Close the connection when the function completes
+	nc, closeConn := connectExample("request-reply-example")
+	defer closeConn() // Drain and close the connection when the function completes
 
-	fmt.Println("Connected to NATS server") // Message about successful connection
+	logger().Info("Connected to NATS server") // Message about successful connection
 
 	// Setup a subscriber to reply to requests
-	_, err = nc.Subscribe("request", func(m *nats.Msg) {
-		fmt.Printf("Received request: %s\n", string(m.Data)) // Print the received request
-		m.Respond([]byte("response"))                        // Send a response to the request
-	})
+	_, err := nc.Subscribe("request", DefaultMetrics.Handler(DefaultTracer.Handler(func(ctx context.Context, m *nats.Msg) {
+		logger().Info("Received request", append(msgAttrs(m), "data", string(m.Data))...) // Log the received request
+		m.Respond([]byte("response"))                                                     // Send a response to the request
+	})))
 	if err != nil {
-		log.Fatal(err) // Log an error if setting up the subscriber fails
+		fatal("Error setting up the subscriber", err) // Log an error if setting up the subscriber fails
 	}
 
-	fmt.Println("Subscriber set up to respond to requests") // Message about successful subscriber setup
+	logger().Info("Subscriber set up to respond to requests") // Message about successful subscriber setup
 
 	// Allow some time for the subscriber to set up
 	time.Sleep(1 * time.Second)
 
+	// Create a client that retries idempotent requests and hedges slow ones
+	client := NewRequestClient(nc, RequestClientOptions{
+		AttemptTimeout: 500 * time.Millisecond, // Deadline of a single attempt
+		Attempts:       3,                      // Retry idempotent requests
+		BaseBackoff:    100 * time.Millisecond, // First pause between retries
+		MaxBackoff:     time.Second,            // Longest pause between retries
+		HedgeDelay:     200 * time.Millisecond, // Race slow attempts with a copy
+		Metrics:        DefaultMetrics,         // Record the latency of every attempt
+		Tracer:         DefaultTracer,          // Trace every attempt through the responder
+	})
+
+	// Bound the whole call with a deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
 	// Send a request and wait for a reply
-	msg, err := nc.Request("request", []byte("hello"), 2*time.Second)
+	msg, err := client.RequestIdempotent(ctx, "request", []byte("hello"))
 	if err != nil {
-		log.Fatal(err) // Log an error if sending the request fails
+		fatal("Error sending the request", err) // Log an error if the request fails after all retries
 	}
 
-	fmt.Printf("Received reply: %s\n", string(msg.Data)) // Print the received reply
+	logger().Info("Received reply", "subject", "request", "data", string(msg.Data)) // Log the received reply
+
+	// A request nobody listens to is reported as such instead of timing out
+	_, err = client.Request(ctx, "nobody.listens", []byte("hello"))
+	switch {
+	case errors.Is(err, nats.ErrNoResponders):
+		logger().Warn("No responders for the request", "subject", "nobody.listens", "error", err) // Message about missing responders
+	case errors.Is(err, ErrRequestTimeout):
+		logger().Warn("Request timed out", "subject", "nobody.listens", "error", err) // Message about the timeout
+	}
+
+	// Log the latency statistics per subject
+	for subject, stats := range client.Stats() {
+		logger().Info("Request statistics", "subject", subject, "requests", stats.Requests, "successes", stats.Successes,
+			"no_responders", stats.NoResponders, "timeouts", stats.Timeouts, "p50", stats.P50, "p99", stats.P99)
+	}
 }
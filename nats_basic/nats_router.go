@@ -0,0 +1,249 @@
+package nats_basic
+
+import (
+	"context" // Import the package for passing route parameters to handlers
+	"fmt"     // Import the package for formatted input/output
+	"strings" // Import the package for splitting subjects into tokens
+	"sync"    // Import the package for protecting the routes
+	"time"    // Import the package for working with time
+
+	"github.com/nats-io/nats.go" // Import the package for working with NATS
+)
+
+// Signature of a routed message handler; named tokens are read from the context with RouteParam
+type RouteHandler func(ctx context.Context, m *nats.Msg)
+
+// Kind of a pattern token, ordered from the least to the most specific
+type tokenKind int
+
+const (
+	tokenTail     tokenKind = iota // ">" matching one or more remaining tokens
+	tokenWildcard                  // "*" or "{name}" matching exactly one token
+	tokenLiteral                   // A literal token
+)
+
+// Token of a route pattern
+type patternToken struct {
+	kind  tokenKind // Kind of the token
+	value string    // Literal value, or the parameter name of a named wildcard
+}
+
+// Registered route
+type route struct {
+	pattern string         // Pattern as registered
+	tokens  []patternToken // Parsed pattern
+	handler RouteHandler   // Handler of the matching messages
+}
+
+// Key of the route parameters in the handler context
+type routeParamsKey struct{}
+
+// Function to get a named token extracted from the subject, e.g. "tenant" for updates.{tenant}.{kind}
+func RouteParam(ctx context.Context, name string) string {
+	params, _ := ctx.Value(routeParamsKey{}).(map[string]string)
+	return params[name]
+}
+
+// Router dispatching messages of one wildcard subscription to the handler of the most specific
+// matching pattern. Patterns use NATS wildcards: "*" matches one token and ">" matches one or more
+// trailing tokens. A "{name}" token matches one token like "*" and is passed to the handler by name.
+type Router struct {
+	mu       sync.RWMutex // Protects the routes
+	routes   []route      // Registered routes
+	NotFound RouteHandler // Optional handler of messages no route matches
+}
+
+// Function to create an empty router
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// Function to register a handler for a pattern
+func (r *Router) Handle(pattern string, handler RouteHandler) error {
+	tokens, err := parsePattern(pattern)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Patterns differing only in parameter names would be ambiguous
+	for _, existing := range r.routes {
+		if samePattern(existing.tokens, tokens) {
+			return fmt.Errorf("pattern %q conflicts with %q", pattern, existing.pattern)
+		}
+	}
+	r.routes = append(r.routes, route{pattern: pattern, tokens: tokens, handler: handler})
+	return nil
+}
+
+// Function to find the handler of the most specific pattern matching a subject and its named tokens
+func (r *Router) Match(subject string) (RouteHandler, map[string]string, bool) {
+	subjectTokens := strings.Split(subject, ".")
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var best *route
+	for i := range r.routes {
+		candidate := &r.routes[i]
+		if !matchTokens(candidate.tokens, subjectTokens) {
+			continue
+		}
+		if best == nil || moreSpecific(candidate.tokens, best.tokens) {
+			best = candidate
+		}
+	}
+	if best == nil {
+		return nil, nil, false
+	}
+
+	// Extract the named tokens of the winning pattern
+	params := map[string]string{}
+	for i, token := range best.tokens {
+		if token.kind == tokenWildcard && token.value != "" {
+			params[token.value] = subjectTokens[i]
+		}
+	}
+	return best.handler, params, true
+}
+
+// Function to dispatch a message to its route, or to NotFound when no route matches
+func (r *Router) Dispatch(m *nats.Msg) {
+	handler, params, ok := r.Match(m.Subject)
+	if !ok {
+		if r.NotFound != nil {
+			r.NotFound(context.Background(), m)
+		}
+		return
+	}
+	handler(context.WithValue(context.Background(), routeParamsKey{}, params), m)
+}
+
+// Function to subscribe to a wildcard subject and dispatch every message through the router
+func (r *Router) Subscribe(nc *nats.Conn, subject string) (*nats.Subscription, error) {
+	return nc.Subscribe(subject, r.Dispatch)
+}
+
+// Function to parse and validate a route pattern
+func parsePattern(pattern string) ([]patternToken, error) {
+	parts := strings.Split(pattern, ".")
+	tokens := make([]patternToken, 0, len(parts))
+	names := map[string]bool{}
+
+	for i, part := range parts {
+		switch {
+		case part == "":
+			return nil, fmt.Errorf("pattern %q has an empty token", pattern)
+		case part == ">":
+			if i != len(parts)-1 {
+				return nil, fmt.Errorf("pattern %q has \">\" before the last token", pattern)
+			}
+			tokens = append(tokens, patternToken{kind: tokenTail})
+		case part == "*":
+			tokens = append(tokens, patternToken{kind: tokenWildcard})
+		case strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}"):
+			name := part[1 : len(part)-1]
+			if name == "" || names[name] {
+				return nil, fmt.Errorf("pattern %q has an empty or repeated token name %q", pattern, name)
+			}
+			names[name] = true
+			tokens = append(tokens, patternToken{kind: tokenWildcard, value: name})
+		case strings.ContainsAny(part, "*>{} \t"):
+			return nil, fmt.Errorf("pattern %q has an invalid token %q", pattern, part)
+		default:
+			tokens = append(tokens, patternToken{kind: tokenLiteral, value: part})
+		}
+	}
+	return tokens, nil
+}
+
+// Function to check whether a pattern matches the tokens of a subject
+func matchTokens(pattern []patternToken, subject []string) bool {
+	for i, token := range pattern {
+		if token.kind == tokenTail {
+			return len(subject) > i // ">" needs at least one more token
+		}
+		if i >= len(subject) || subject[i] == "" {
+			return false
+		}
+		if token.kind == tokenLiteral && token.value != subject[i] {
+			return false
+		}
+	}
+	return len(pattern) == len(subject)
+}
+
+// Function to check whether pattern a is more specific than pattern b. The first differing token
+// decides: a literal beats a wildcard, which beats ">".
+func moreSpecific(a, b []patternToken) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i].kind != b[i].kind {
+			return a[i].kind > b[i].kind
+		}
+	}
+	return len(a) > len(b)
+}
+
+// Function to check whether two patterns match exactly the same subjects
+func samePattern(a, b []patternToken) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].kind != b[i].kind || (a[i].kind == tokenLiteral && a[i].value != b[i].value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Function to demonstrate routing updates of many tenants through one wildcard subscription
+func RouterExample() {
+	// Log a message about launching the router example
+	logger().Info("Example of using a subject router NATS")
+
+	// Connect to NATS server
+	nc, closeConn := connectExample("router-example")
+	defer closeConn() // Drain and close the connection when the function completes
+
+	logger().Info("Connected to NATS server") // Message about successful connection
+
+	// Register the handlers in any order, the most specific matching pattern wins
+	router := NewRouter()
+	routes := map[string]RouteHandler{
+		"updates.>": func(ctx context.Context, m *nats.Msg) {
+			logger().Info("Generic update", append(msgAttrs(m), "data", string(m.Data))...) // Log an update no other route handles
+		},
+		"updates.{tenant}.{kind}": func(ctx context.Context, m *nats.Msg) {
+			logger().Info("Tenant update", append(msgAttrs(m), "tenant", RouteParam(ctx, "tenant"), "kind", RouteParam(ctx, "kind"), "data", string(m.Data))...) // Log a tenant update
+		},
+		"updates.{tenant}.billing": func(ctx context.Context, m *nats.Msg) {
+			logger().Info("Billing update", append(msgAttrs(m), "tenant", RouteParam(ctx, "tenant"), "data", string(m.Data))...) // Log a billing update
+		},
+	}
+	for pattern, handler := range routes {
+		if err := router.Handle(pattern, handler); err != nil {
+			fatal("Invalid route pattern", err) // Log an error if the pattern is invalid
+		}
+	}
+
+	// Dispatch every update from a single subscription
+	if _, err := router.Subscribe(nc, "updates.>"); err != nil {
+		fatal("Error setting up the subscription", err) // Log an error if setting up the subscription fails
+	}
+
+	logger().Info("Router subscribed") // Message about successful subscription
+
+	// Publish updates matching each route
+	for _, subject := range []string{"updates.acme.profile", "updates.acme.billing", "updates.acme.orders.42"} {
+		if err := nc.Publish(subject, []byte("changed")); err != nil {
+			fatal("Error publishing the message", err) // Log an error if publishing the message fails
+		}
+	}
+	nc.Flush() // Make sure the updates reached the server
+
+	// Allow some time for the router to receive the messages
+	time.Sleep(500 * time.Millisecond)
+}
@@ -0,0 +1,203 @@
+package nats_basic
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+)
+
+func TestParsePatternRejectsInvalidPatterns(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+	}{
+		{"empty pattern", ""},
+		{"empty token in the middle", "orders..created"},
+		{"leading dot", ".orders"},
+		{"trailing dot", "orders."},
+		{"tail in the middle", "orders.>.created"},
+		{"tail first", ">.orders"},
+		{"wildcard inside a token", "orders.a*"},
+		{"tail inside a token", "orders.a>"},
+		{"empty parameter name", "orders.{}"},
+		{"repeated parameter name", "orders.{id}.{id}"},
+		{"unclosed parameter", "orders.{id"},
+		{"space in a token", "orders.new order"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parsePattern(tt.pattern); err == nil {
+				t.Errorf("parsePattern(%q) succeeded, want an error", tt.pattern)
+			}
+		})
+	}
+}
+
+func TestParsePatternAcceptsValidPatterns(t *testing.T) {
+	for _, pattern := range []string{"orders", "orders.*", "orders.>", ">", "*", "orders.{id}.*.>", "updates.{tenant}.{kind}"} {
+		if _, err := parsePattern(pattern); err != nil {
+			t.Errorf("parsePattern(%q) failed: %v", pattern, err)
+		}
+	}
+}
+
+func TestMatchTokens(t *testing.T) {
+	tests := []struct {
+		pattern string
+		subject string
+		want    bool
+	}{
+		// "*" matches exactly one token
+		{"orders.*", "orders.created", true},
+		{"orders.*", "orders", false},
+		{"orders.*", "orders.created.eu", false},
+		{"*.created", "orders.created", true},
+		{"orders.*.eu", "orders.created.eu", true},
+		{"orders.*.eu", "orders.created.us", false},
+
+		// ">" matches one or more trailing tokens
+		{"orders.>", "orders.created", true},
+		{"orders.>", "orders.created.eu.1", true},
+		{"orders.>", "orders", false},
+		{">", "orders", true},
+
+		// Named tokens behave like "*"
+		{"updates.{tenant}.{kind}", "updates.acme.profile", true},
+		{"updates.{tenant}.{kind}", "updates.acme", false},
+		{"updates.{tenant}.{kind}", "updates.acme.orders.42", false},
+
+		// Literals match only themselves
+		{"orders.created", "orders.created", true},
+		{"orders.created", "orders.deleted", false},
+		{"orders", "orders.created", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.subject, func(t *testing.T) {
+			tokens, err := parsePattern(tt.pattern)
+			if err != nil {
+				t.Fatalf("parsePattern(%q): %v", tt.pattern, err)
+			}
+			if got := matchTokens(tokens, strings.Split(tt.subject, ".")); got != tt.want {
+				t.Errorf("matchTokens(%q, %q) = %v, want %v", tt.pattern, tt.subject, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouterMatchPrefersMostSpecific(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		subject  string
+		want     string
+	}{
+		{"literal beats wildcard", []string{"orders.*", "orders.created"}, "orders.created", "orders.created"},
+		{"wildcard beats tail", []string{"orders.>", "orders.*"}, "orders.created", "orders.*"},
+		{"literal beats tail", []string{"orders.>", "orders.created"}, "orders.created", "orders.created"},
+		{"named token ranks as wildcard", []string{"orders.>", "orders.{kind}"}, "orders.created", "orders.{kind}"},
+		{"first differing token decides", []string{"updates.{tenant}.billing", "updates.acme.{kind}"}, "updates.acme.billing", "updates.acme.{kind}"},
+		{"tail used when nothing else matches", []string{"orders.>", "orders.*"}, "orders.created.eu", "orders.>"},
+		{"longer literal prefix wins", []string{"updates.>", "updates.{tenant}.{kind}", "updates.{tenant}.billing"}, "updates.acme.billing", "updates.{tenant}.billing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The winner must not depend on the registration order
+			for _, patterns := range [][]string{tt.patterns, reversed(tt.patterns)} {
+				router := NewRouter()
+				var got string
+				for _, pattern := range patterns {
+					pattern := pattern
+					if err := router.Handle(pattern, func(ctx context.Context, m *nats.Msg) { got = pattern }); err != nil {
+						t.Fatalf("Handle(%q): %v", pattern, err)
+					}
+				}
+
+				handler, _, ok := router.Match(tt.subject)
+				if !ok {
+					t.Fatalf("Match(%q) found no route", tt.subject)
+				}
+				handler(context.Background(), &nats.Msg{Subject: tt.subject})
+				if got != tt.want {
+					t.Errorf("Match(%q) with %v chose %q, want %q", tt.subject, patterns, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRouterDispatchPassesNamedTokens(t *testing.T) {
+	router := NewRouter()
+	var tenant, kind string
+	err := router.Handle("updates.{tenant}.{kind}", func(ctx context.Context, m *nats.Msg) {
+		tenant, kind = RouteParam(ctx, "tenant"), RouteParam(ctx, "kind")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router.Dispatch(&nats.Msg{Subject: "updates.acme.profile"})
+	if tenant != "acme" || kind != "profile" {
+		t.Errorf("got tenant %q and kind %q, want acme and profile", tenant, kind)
+	}
+}
+
+func TestRouterDispatchNotFound(t *testing.T) {
+	router := NewRouter()
+	if err := router.Handle("orders.*", func(ctx context.Context, m *nats.Msg) { t.Error("unexpected route") }); err != nil {
+		t.Fatal(err)
+	}
+
+	var notFound string
+	router.NotFound = func(ctx context.Context, m *nats.Msg) { notFound = m.Subject }
+	router.Dispatch(&nats.Msg{Subject: "payments.created"})
+	if notFound != "payments.created" {
+		t.Errorf("NotFound got %q, want payments.created", notFound)
+	}
+	if RouteParam(context.Background(), "missing") != "" {
+		t.Error("RouteParam without parameters should be empty")
+	}
+}
+
+func TestRouterHandleRejectsConflicts(t *testing.T) {
+	tests := []struct {
+		name     string
+		first    string
+		second   string
+		conflict bool
+	}{
+		{"duplicate literal", "orders.created", "orders.created", true},
+		{"duplicate wildcard", "orders.*", "orders.*", true},
+		{"wildcard and named token", "orders.*", "orders.{kind}", true},
+		{"differently named tokens", "updates.{tenant}.{kind}", "updates.{org}.{type}", true},
+		{"duplicate tail", "orders.>", "orders.>", true},
+		{"different literals", "orders.created", "orders.deleted", false},
+		{"wildcard and tail", "orders.*", "orders.>", false},
+		{"different lengths", "orders.*", "orders.*.*", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewRouter()
+			noop := func(ctx context.Context, m *nats.Msg) {}
+			if err := router.Handle(tt.first, noop); err != nil {
+				t.Fatalf("Handle(%q): %v", tt.first, err)
+			}
+			err := router.Handle(tt.second, noop)
+			if tt.conflict && err == nil {
+				t.Errorf("Handle(%q) after %q succeeded, want a conflict", tt.second, tt.first)
+			}
+			if !tt.conflict && err != nil {
+				t.Errorf("Handle(%q) after %q failed: %v", tt.second, tt.first, err)
+			}
+		})
+	}
+}
+
+func reversed(s []string) []string {
+	out := make([]string, len(s))
+	for i, v := range s {
+		out[len(s)-1-i] = v
+	}
+	return out
+}
@@ -0,0 +1,152 @@
+package nats_basic
+
+import (
+	"context"       // Import the package for cancellation and deadlines
+	"encoding/json" // Import the package for encoding requests, responses and errors
+	"errors"        // Import the package for working with errors
+	"fmt"           // Import the package for formatted input/output
+	"time"          // Import the package for working with time
+
+	"github.com/nats-io/nats.go" // Import the package for working with NATS
+)
+
+// Header carrying the caller deadline in RFC 3339 format, applied to the handler context
+const RPCDeadlineHeader = "Rpc-Deadline"
+
+// Error code used when a request cannot be decoded; handler failures that are not a *ServiceError
+// use InternalErrorCode, like service handlers
+const BadRequestErrorCode = "400"
+
+// Typed endpoint whose requests and responses are encoded as JSON
+type Endpoint[Req, Resp any] struct {
+	Subject string // Subject the endpoint is served on
+}
+
+// Function to define a typed endpoint on the given subject
+func NewEndpoint[Req, Resp any](subject string) Endpoint[Req, Resp] {
+	return Endpoint[Req, Resp]{Subject: subject}
+}
+
+// Function to serve the endpoint with a typed handler; a non-empty queue load balances between servers
+func (e Endpoint[Req, Resp]) Serve(nc *nats.Conn, queue string, handler func(ctx context.Context, req Req) (Resp, error)) (*nats.Subscription, error) {
+	return nc.QueueSubscribe(e.Subject, queue, func(m *nats.Msg) {
+		// Apply the caller deadline so the handler does not work past it
+		ctx := context.Background()
+		if deadline, err := time.Parse(time.RFC3339Nano, m.Header.Get(RPCDeadlineHeader)); err == nil {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithDeadline(ctx, deadline)
+			defer cancel()
+		}
+
+		var req Req
+		if err := json.Unmarshal(m.Data, &req); err != nil {
+			RespondServiceError(m, NewServiceError(BadRequestErrorCode, err.Error()))
+			return
+		}
+
+		resp, err := handler(ctx, req)
+		if err != nil {
+			// Keep service errors as they are and send everything else with the internal code
+			var serviceErr *ServiceError
+			if !errors.As(err, &serviceErr) {
+				serviceErr = NewServiceError(InternalErrorCode, err.Error())
+			}
+			RespondServiceError(m, serviceErr)
+			return
+		}
+
+		data, err := json.Marshal(resp)
+		if err != nil {
+			RespondServiceError(m, NewServiceError(InternalErrorCode, err.Error()))
+			return
+		}
+		m.Respond(data) // Send the encoded response
+	})
+}
+
+// Function to call the endpoint and decode the typed response; handler errors are returned as *ServiceError
+func (e Endpoint[Req, Resp]) Call(ctx context.Context, nc *nats.Conn, req Req) (Resp, error) {
+	var resp Resp
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return resp, fmt.Errorf("encode request for %s: %w", e.Subject, err)
+	}
+
+	msg := nats.NewMsg(e.Subject)
+	msg.Data = data
+	if deadline, ok := ctx.Deadline(); ok {
+		msg.Header.Set(RPCDeadlineHeader, deadline.UTC().Format(time.RFC3339Nano))
+	}
+
+	reply, err := nc.RequestMsgWithContext(ctx, msg)
+	if err != nil {
+		return resp, fmt.Errorf("call %s: %w", e.Subject, err)
+	}
+
+	// A failed call carries the error in the service error headers
+	if serviceErr := ServiceErrorFromMsg(reply); serviceErr != nil {
+		return resp, serviceErr
+	}
+
+	if err := json.Unmarshal(reply.Data, &resp); err != nil {
+		return resp, fmt.Errorf("decode response from %s: %w", e.Subject, err)
+	}
+	return resp, nil
+}
+
+// Request of the example greeting endpoint
+type GreetRequest struct {
+	Name string `json:"name"` // Name of the person to greet
+}
+
+// Response of the example greeting endpoint
+type GreetResponse struct {
+	Message string `json:"message"` // Greeting message
+}
+
+// Function to demonstrate typed RPC on top of request-reply
+func TypedRPCExample() {
+	// Log a message about launching the typed RPC example
+	logger().Info("Example of using typed RPC over Request-Reply NATS")
+
+	// Connect to NATS server
+	nc, closeConn := connectExample("rpc-example")
+	defer closeConn() // Drain and close the connection when the function completes
+
+	logger().Info("Connected to NATS server") // Message about successful connection
+
+	// Define the endpoint once and share it between server and client
+	greet := NewEndpoint[GreetRequest, GreetResponse]("rpc.greet")
+
+	// Serve the endpoint with a typed handler
+	_, err := greet.Serve(nc, "greeters", func(ctx context.Context, req GreetRequest) (GreetResponse, error) {
+		if req.Name == "" {
+			return GreetResponse{}, NewServiceError(BadRequestErrorCode, "name is required")
+		}
+		return GreetResponse{Message: "Hello, " + req.Name + "!"}, nil
+	})
+	if err != nil {
+		fatal("Error serving the endpoint", err) // Log an error if serving the endpoint fails
+	}
+
+	logger().Info("Greeting endpoint served") // Message about successful endpoint setup
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	// Call the endpoint with a valid and an invalid request
+	for _, name := range []string{"World", ""} {
+		resp, err := greet.Call(ctx, nc, GreetRequest{Name: name})
+
+		var serviceErr *ServiceError
+		switch {
+		case errors.As(err, &serviceErr):
+			logger().Warn("Call failed", "subject", greet.Subject, "code", serviceErr.Code, "error", serviceErr.Description) // Log the structured error
+		case err != nil:
+			fatal("Error calling the endpoint", err) // Log an error if the call itself fails
+		default:
+			logger().Info("Call returned", "subject", greet.Subject, "message", resp.Message) // Log the typed response
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package nats_basic
+
+import (
+	"context" // Import the package for cancellation and deadlines
+	"errors"  // Import the package for working with errors
+	"fmt"     // Import the package for formatted input/output
+	"time"    // Import the package for working with time
+
+	"github.com/nats-io/nats.go" // Import the package for working with NATS
+)
+
+// Header carrying the identity of the responder in scatter-gather replies
+const ResponderHeader = "Responder-Id"
+
+// Deadline used by ScatterGather when neither the options nor the context set one
+const DefaultGatherTimeout = 2 * time.Second
+
+// Conditions ending a scatter-gather request; whichever is reached first wins
+type GatherOptions struct {
+	MaxReplies int           // Stop after this many replies, zero means no limit
+	IdleGap    time.Duration // Stop when no reply arrives for this long after the last one, zero disables it
+	Timeout    time.Duration // Stop at this deadline, zero uses the context deadline or DefaultGatherTimeout
+}
+
+// Reply collected by a scatter-gather request
+type GatherReply struct {
+	Responder string        // Identity sent by the responder in the Responder-Id header, empty if not set
+	Msg       *nats.Msg     // Reply message
+	Latency   time.Duration // Time between sending the request and receiving the reply
+}
+
+// Function to reply to a scatter-gather request, identifying the responder
+func RespondAs(msg *nats.Msg, responder string, data []byte) error {
+	reply := nats.NewMsg(msg.Reply)
+	reply.Header.Set(ResponderHeader, responder)
+	reply.Data = data
+	return msg.RespondMsg(reply)
+}
+
+// Function to check whether a reply is the server status telling there is nobody listening
+func isNoResponders(msg *nats.Msg) bool {
+	return len(msg.Data) == 0 && msg.Header.Get("Status") == "503"
+}
+
+// Function to publish a request and collect replies from every responder until the maximum number
+// of replies, the idle gap or the deadline is reached. Reaching any of them is not an error; the
+// replies received so far are returned. nats.ErrNoResponders is returned when nobody listens.
+func ScatterGather(ctx context.Context, nc *nats.Conn, subject string, data []byte, opts GatherOptions) ([]GatherReply, error) {
+	// Make sure the request always ends
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	} else if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultGatherTimeout)
+		defer cancel()
+	}
+
+	// Collect the replies on a private inbox
+	inbox := nc.NewRespInbox()
+	replies := make(chan *nats.Msg, 256)
+	sub, err := nc.ChanSubscribe(inbox, replies)
+	if err != nil {
+		return nil, fmt.Errorf("subscribe to reply inbox: %w", err)
+	}
+	defer sub.Unsubscribe() // Stop receiving replies when gathering completes
+
+	start := time.Now()
+	if err := nc.PublishRequest(subject, inbox, data); err != nil {
+		return nil, fmt.Errorf("publish request to %s: %w", subject, err)
+	}
+
+	// The idle timer only starts once the first reply has arrived
+	var idle <-chan time.Time
+	var idleTimer *time.Timer
+	defer func() {
+		if idleTimer != nil {
+			idleTimer.Stop()
+		}
+	}()
+
+	var gathered []GatherReply
+	for {
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return gathered, nil // The deadline ends gathering normally
+			}
+			return gathered, ctx.Err()
+		case <-idle:
+			return gathered, nil // Responders went quiet
+		case msg := <-replies:
+			if len(gathered) == 0 && isNoResponders(msg) {
+				return nil, fmt.Errorf("request to %s: %w", subject, nats.ErrNoResponders)
+			}
+
+			gathered = append(gathered, GatherReply{
+				Responder: msg.Header.Get(ResponderHeader),
+				Msg:       msg,
+				Latency:   time.Since(start),
+			})
+			if opts.MaxReplies > 0 && len(gathered) >= opts.MaxReplies {
+				return gathered, nil // Enough replies were collected
+			}
+
+			// Restart the idle gap after every reply
+			if opts.IdleGap > 0 {
+				if idleTimer == nil {
+					idleTimer = time.NewTimer(opts.IdleGap)
+					idle = idleTimer.C
+				} else {
+					// Drain a tick that fired meanwhile, so it cannot end gathering right after the reset
+					if !idleTimer.Stop() {
+						select {
+						case <-idleTimer.C:
+						default:
+						}
+					}
+					idleTimer.Reset(opts.IdleGap)
+				}
+			}
+		}
+	}
+}
+
+// Function to demonstrate an inventory lookup across regional services using scatter-gather
+func ScatterGatherExample() {
+	// Log a message about launching the scatter-gather example
+	logger().Info("Example of using scatter-gather requests NATS")
+
+	// Connect to NATS server
+	nc, closeConn := connectExample("scatter-gather-example")
+	defer closeConn() // Drain and close the connection when the function completes
+
+	logger().Info("Connected to NATS server") // Message about successful connection
+
+	// Setup one inventory responder per region
+	stock := map[string]string{"eu": "12", "us": "7", "apac": "3"}
+	for region, count := range stock {
+		_, err := nc.Subscribe("inventory.lookup", func(m *nats.Msg) {
+			RespondAs(m, region, []byte(count)) // Reply with the regional stock level
+		})
+		if err != nil {
+			fatal("Error setting up the responder", err) // Log an error if setting up the responder fails
+		}
+	}
+
+	logger().Info("Regional responders set up") // Message about successful responder setup
+
+	// Ask every region and collect the replies
+	replies, err := ScatterGather(context.Background(), nc, "inventory.lookup", []byte("apple"), GatherOptions{
+		MaxReplies: len(stock),             // Stop once every region answered
+		IdleGap:    200 * time.Millisecond, // Or when the regions go quiet
+		Timeout:    2 * time.Second,        // Or at the deadline
+	})
+	if err != nil {
+		fatal("Error looking up the stock", err) // Log an error if the lookup fails
+	}
+
+	for _, reply := range replies {
+		logger().Info("Regional stock", "subject", reply.Msg.Subject, "region", reply.Responder, "stock", string(reply.Msg.Data), "latency", reply.Latency) // Log every regional reply
+	}
+
+	// A lookup nobody listens to fails fast
+	_, err = ScatterGather(context.Background(), nc, "inventory.nobody", nil, GatherOptions{Timeout: time.Second})
+	logger().Warn("Lookup without responders", "subject", "inventory.nobody", "error", err) // Log the no-responders error
+}
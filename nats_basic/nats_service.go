@@ -0,0 +1,258 @@
+package nats_basic
+
+import (
+	"context"       // Import the package for cancellation
+	"encoding/json" // Import the package for decoding discovery responses
+	"errors"        // Import the package for working with errors
+	"fmt"           // Import the package for formatted input/output
+	"time"          // Import the package for working with time
+
+	"github.com/nats-io/nats.go"       // Import the package for working with NATS
+	"github.com/nats-io/nats.go/micro" // Import the package for building NATS services
+)
+
+// Error code used when a handler fails with an error that is not a ServiceError
+const InternalErrorCode = "500"
+
+// Error returned by a service handler with a code and description sent back to the caller
+type ServiceError struct {
+	Code        string // Error code, sent in the Nats-Service-Error-Code header
+	Description string // Error description, sent in the Nats-Service-Error header
+}
+
+// Function to create a service error with the given code and description
+func NewServiceError(code, description string) *ServiceError {
+	return &ServiceError{Code: code, Description: description}
+}
+
+// Function to format the service error
+func (e *ServiceError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+// Function to get the service error carried by a response, nil for a successful response.
+// Services and typed RPC endpoints both send errors in the micro error headers.
+func ServiceErrorFromMsg(msg *nats.Msg) *ServiceError {
+	code := msg.Header.Get(micro.ErrorCodeHeader)
+	if code == "" {
+		return nil
+	}
+	return NewServiceError(code, msg.Header.Get(micro.ErrorHeader))
+}
+
+// Function to reply to a request with a service error in the micro error headers and an empty body
+func RespondServiceError(m *nats.Msg, serviceErr *ServiceError) error {
+	reply := nats.NewMsg(m.Reply)
+	reply.Header.Set(micro.ErrorCodeHeader, serviceErr.Code)
+	reply.Header.Set(micro.ErrorHeader, serviceErr.Description)
+	return m.RespondMsg(reply)
+}
+
+// Signature of a service endpoint handler; a returned error is sent to the caller as an error response
+type ServiceHandler func(req micro.Request) ([]byte, error)
+
+// Endpoint of a service
+type ServiceEndpoint struct {
+	Group      string            // Subject prefix the endpoint is registered under, e.g. "inventory.v1"
+	Name       string            // Endpoint name, also the last subject token unless Subject is set
+	Subject    string            // Optional subject overriding the name
+	QueueGroup string            // Optional queue group overriding the service one
+	Metadata   map[string]string // Optional metadata reported by INFO
+	Handler    ServiceHandler    // Handler of the requests
+}
+
+// Definition of a service and its endpoints
+type ServiceDefinition struct {
+	Name        string            // Service name, shared by all instances
+	Version     string            // SemVer version of the service
+	Description string            // Free-form description reported by INFO
+	QueueGroup  string            // Queue group load balancing requests across instances, empty uses the default
+	Metadata    map[string]string // Optional metadata reported by PING, INFO and STATS
+	Endpoints   []ServiceEndpoint // Endpoints of the service
+}
+
+// Function to adapt a service handler to the micro handler interface
+func serviceHandler(handler ServiceHandler) micro.Handler {
+	return micro.HandlerFunc(func(req micro.Request) {
+		response, err := handler(req)
+		if err == nil {
+			req.Respond(response) // Send the successful response
+			return
+		}
+
+		// Handler errors become error responses counted in the endpoint stats
+		var serviceErr *ServiceError
+		if !errors.As(err, &serviceErr) {
+			serviceErr = NewServiceError(InternalErrorCode, err.Error())
+		}
+		req.Error(serviceErr.Code, serviceErr.Description, nil)
+	})
+}
+
+// Function to start a service; it answers $SRV.PING, $SRV.INFO and $SRV.STATS automatically
+func StartService(nc *nats.Conn, def ServiceDefinition) (micro.Service, error) {
+	svc, err := micro.AddService(nc, micro.Config{
+		Name:        def.Name,
+		Version:     def.Version,
+		Description: def.Description,
+		QueueGroup:  def.QueueGroup,
+		Metadata:    def.Metadata,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("add service %q: %w", def.Name, err)
+	}
+
+	// Register every endpoint, sharing groups with the same prefix
+	groups := map[string]micro.Group{}
+	for _, endpoint := range def.Endpoints {
+		var opts []micro.EndpointOpt
+		if endpoint.Subject != "" {
+			opts = append(opts, micro.WithEndpointSubject(endpoint.Subject))
+		}
+		if endpoint.QueueGroup != "" {
+			opts = append(opts, micro.WithEndpointQueueGroup(endpoint.QueueGroup))
+		}
+		if endpoint.Metadata != nil {
+			opts = append(opts, micro.WithEndpointMetadata(endpoint.Metadata))
+		}
+
+		handler := serviceHandler(endpoint.Handler)
+		if endpoint.Group == "" {
+			err = svc.AddEndpoint(endpoint.Name, handler, opts...)
+		} else {
+			group, ok := groups[endpoint.Group]
+			if !ok {
+				group = svc.AddGroup(endpoint.Group)
+				groups[endpoint.Group] = group
+			}
+			err = group.AddEndpoint(endpoint.Name, handler, opts...)
+		}
+		if err != nil {
+			svc.Stop() // Do not leave a half-registered service behind
+			return nil, fmt.Errorf("add endpoint %q to service %q: %w", endpoint.Name, def.Name, err)
+		}
+	}
+	return svc, nil
+}
+
+// Function to send a discovery request for a verb and decode every reply received before the timeout.
+// An empty name asks all services.
+func discover[T any](nc *nats.Conn, verb micro.Verb, name string, timeout time.Duration) ([]T, error) {
+	subject, err := micro.ControlSubject(verb, name, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// Every running instance answers, so gather replies until the timeout
+	replies, err := ScatterGather(context.Background(), nc, subject, nil, GatherOptions{Timeout: timeout})
+	if errors.Is(err, nats.ErrNoResponders) {
+		return []T{}, nil // No instance is running
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]T, 0, len(replies))
+	for _, reply := range replies {
+		var result T
+		if err := json.Unmarshal(reply.Msg.Data, &result); err != nil {
+			return results, fmt.Errorf("decode %s response: %w", verb, err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// Function to discover running service instances and their endpoints
+func DiscoverServices(nc *nats.Conn, name string, timeout time.Duration) ([]micro.Info, error) {
+	return discover[micro.Info](nc, micro.InfoVerb, name, timeout)
+}
+
+// Function to collect the endpoint statistics of running service instances
+func DiscoverServiceStats(nc *nats.Conn, name string, timeout time.Duration) ([]micro.Stats, error) {
+	return discover[micro.Stats](nc, micro.StatsVerb, name, timeout)
+}
+
+// Function to ping running service instances
+func PingServices(nc *nats.Conn, name string, timeout time.Duration) ([]micro.Ping, error) {
+	return discover[micro.Ping](nc, micro.PingVerb, name, timeout)
+}
+
+// Function to demonstrate a service with versioned endpoints, discovery and error responses
+func ServiceExample() {
+	// Log a message about launching the service example
+	logger().Info("Example of using NATS services")
+
+	// Connect to NATS server
+	nc, closeConn := connectExample("service-example")
+	defer closeConn() // Drain and close the connection when the function completes
+
+	logger().Info("Connected to NATS server") // Message about successful connection
+
+	// Stock levels served by the inventory service
+	stock := map[string]int{"apple": 10, "pear": 0}
+
+	// Define a versioned inventory service
+	def := ServiceDefinition{
+		Name:        "inventory",
+		Version:     "1.0.0",
+		Description: "Stock levels of products",
+		Endpoints: []ServiceEndpoint{
+			{
+				Group: "inventory.v1",
+				Name:  "stock",
+				Handler: func(req micro.Request) ([]byte, error) {
+					count, ok := stock[string(req.Data())]
+					if !ok {
+						return nil, NewServiceError("404", "unknown product "+string(req.Data()))
+					}
+					return []byte(fmt.Sprint(count)), nil
+				},
+			},
+		},
+	}
+
+	// Start two instances sharing the queue group, so requests are load balanced
+	for i := 0; i < 2; i++ {
+		svc, err := StartService(nc, def)
+		if err != nil {
+			fatal("Error starting the service", err) // Log an error if starting the service fails
+		}
+		defer svc.Stop() // Stop the service when the function completes
+	}
+
+	logger().Info("Inventory service started") // Message about successful service start
+
+	// Call the endpoint with a known and an unknown product
+	for _, product := range []string{"apple", "banana"} {
+		msg, err := nc.Request("inventory.v1.stock", []byte(product), 2*time.Second)
+		if err != nil {
+			fatal("Error sending the request", err) // Log an error if sending the request fails
+		}
+		if serviceErr := ServiceErrorFromMsg(msg); serviceErr != nil {
+			logger().Warn("Stock lookup failed", "subject", "inventory.v1.stock", "product", product, "code", serviceErr.Code, "error", serviceErr.Description) // Log the error response
+		} else {
+			logger().Info("Stock level", "subject", "inventory.v1.stock", "product", product, "stock", string(msg.Data)) // Log the stock level
+		}
+	}
+
+	// Discover the running instances
+	infos, err := DiscoverServices(nc, "inventory", time.Second)
+	if err != nil {
+		fatal("Error discovering services", err) // Log an error if discovery fails
+	}
+	for _, info := range infos {
+		logger().Info("Discovered service", "service", info.Name, "version", info.Version, "id", info.ID, "endpoints", len(info.Endpoints)) // Log the discovered instance
+	}
+
+	// Collect the endpoint statistics of the instances
+	stats, err := DiscoverServiceStats(nc, "inventory", time.Second)
+	if err != nil {
+		fatal("Error collecting the stats", err) // Log an error if collecting the stats fails
+	}
+	for _, s := range stats {
+		for _, endpoint := range s.Endpoints {
+			logger().Info("Endpoint statistics", "id", s.ID, "endpoint", endpoint.Name, "subject", endpoint.Subject, "requests", endpoint.NumRequests, "errors", endpoint.NumErrors) // Log the endpoint statistics
+		}
+	}
+}
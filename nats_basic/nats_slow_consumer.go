@@ -0,0 +1,145 @@
+package nats_basic
+
+import (
+	"errors"   // Import the package for working with errors
+	"fmt"      // Import the package for formatted input/output
+	"log/slog" // Import the package for structured logging
+	"time"     // Import the package for working with time
+
+	"github.com/nats-io/nats.go" // Import the package for working with NATS
+)
+
+// Limits of the messages buffered by an async subscription before it becomes a slow consumer
+type PendingLimits struct {
+	Msgs  int // Pending messages allowed, zero keeps the default and -1 disables the limit
+	Bytes int // Pending bytes allowed, zero keeps the default and -1 disables the limit
+}
+
+// Function to apply pending limits to a subscription
+func ApplyPendingLimits(sub *nats.Subscription, limits PendingLimits) error {
+	msgs, bytes, err := sub.PendingLimits()
+	if err != nil {
+		return err
+	}
+	if limits.Msgs != 0 {
+		msgs = limits.Msgs
+	}
+	if limits.Bytes != 0 {
+		bytes = limits.Bytes
+	}
+	return sub.SetPendingLimits(msgs, bytes)
+}
+
+// Snapshot of the delivery statistics of a subscription
+type SubscriptionStats struct {
+	Subject         string `json:"subject"`           // Subject of the subscription
+	Queue           string `json:"queue,omitempty"`   // Queue group, empty for a plain subscription
+	PendingMsgs     int    `json:"pending_msgs"`      // Messages buffered but not yet handled
+	PendingBytes    int    `json:"pending_bytes"`     // Bytes buffered but not yet handled
+	MaxPendingMsgs  int    `json:"max_pending_msgs"`  // Highest number of buffered messages seen
+	MaxPendingBytes int    `json:"max_pending_bytes"` // Highest number of buffered bytes seen
+	Delivered       int64  `json:"delivered"`         // Messages delivered to the handler
+	Dropped         int    `json:"dropped"`           // Messages dropped because the pending limits were exceeded
+}
+
+// Function to take a snapshot of the statistics of a subscription
+func SnapshotSubscription(sub *nats.Subscription) (SubscriptionStats, error) {
+	stats := SubscriptionStats{Subject: sub.Subject, Queue: sub.Queue}
+
+	var err error
+	if stats.PendingMsgs, stats.PendingBytes, err = sub.Pending(); err != nil {
+		return stats, err
+	}
+	if stats.MaxPendingMsgs, stats.MaxPendingBytes, err = sub.MaxPending(); err != nil {
+		return stats, err
+	}
+	if stats.Delivered, err = sub.Delivered(); err != nil {
+		return stats, err
+	}
+	if stats.Dropped, err = sub.Dropped(); err != nil {
+		return stats, err
+	}
+	return stats, nil
+}
+
+// Function to log the statistics of a subscription as a group of attributes
+func (s SubscriptionStats) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("subject", s.Subject),
+		slog.String("queue", s.Queue),
+		slog.Int("pending_msgs", s.PendingMsgs),
+		slog.Int("pending_bytes", s.PendingBytes),
+		slog.Int("max_pending_msgs", s.MaxPendingMsgs),
+		slog.Int("max_pending_bytes", s.MaxPendingBytes),
+		slog.Int64("delivered", s.Delivered),
+		slog.Int("dropped", s.Dropped),
+	)
+}
+
+// Function to create an async error handler reporting slow consumers with the subscription statistics.
+// Other async errors are logged. Use it with the nats.ErrorHandler connection option.
+func SlowConsumerHandler(report func(stats SubscriptionStats)) nats.ErrHandler {
+	return func(nc *nats.Conn, sub *nats.Subscription, err error) {
+		if !errors.Is(err, nats.ErrSlowConsumer) || sub == nil {
+			logger().Error("NATS async error", "error", err) // Log other async errors
+			return
+		}
+
+		stats, statsErr := SnapshotSubscription(sub)
+		if statsErr != nil {
+			logger().Warn("Slow consumer", "subject", sub.Subject, "error", statsErr) // The subscription was closed meanwhile
+			return
+		}
+		report(stats)
+	}
+}
+
+// Function to report a slow consumer in the log
+func logSlowConsumer(stats SubscriptionStats) {
+	logger().Warn("Slow consumer", "subject", stats.Subject, "dropped", stats.Dropped, "pending_msgs", stats.PendingMsgs)
+}
+
+// Function to demonstrate a subscription that cannot keep up with its publisher
+func SlowConsumerExample() {
+	// Log a message about launching the slow consumer example
+	logger().Info("Example of detecting slow consumers NATS")
+
+	// Connect to NATS server, reporting slow consumers
+	nc, closeConn := connectExample("slow-consumer-example", nats.ErrorHandler(SlowConsumerHandler(logSlowConsumer)))
+	defer closeConn() // Drain and close the connection when the function completes
+
+	logger().Info("Connected to NATS server") // Message about successful connection
+
+	// Setup a subscriber that handles messages slower than they arrive
+	sub, err := nc.Subscribe("metrics.raw", func(m *nats.Msg) {
+		time.Sleep(10 * time.Millisecond) // Simulate slow processing
+	})
+	if err != nil {
+		fatal("Error setting up the subscriber", err) // Log an error if setting up the subscriber fails
+	}
+	defer sub.Unsubscribe() // Stop the slow subscriber when the function completes
+
+	// Buffer at most 10 messages, so a burst overflows the subscription
+	if err := ApplyPendingLimits(sub, PendingLimits{Msgs: 10, Bytes: -1}); err != nil {
+		fatal("Error setting the limits", err) // Log an error if setting the limits fails
+	}
+
+	logger().Info("Slow subscriber set up") // Message about successful subscriber setup
+
+	// Publish a burst of messages
+	for i := 1; i <= 50; i++ {
+		if err := nc.Publish("metrics.raw", []byte(fmt.Sprint(i))); err != nil {
+			fatal("Error publishing the message", err) // Log an error if publishing the message fails
+		}
+	}
+	nc.Flush() // Make sure the burst reached the subscriber
+
+	// Allow some time for the subscriber to work through its buffer
+	time.Sleep(300 * time.Millisecond)
+
+	stats, err := SnapshotSubscription(sub)
+	if err != nil {
+		fatal("Error reading the statistics", err) // Log an error if reading the statistics fails
+	}
+	logger().Info("Subscription statistics", "stats", stats) // Log the delivered and dropped counts
+}
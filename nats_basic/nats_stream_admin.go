@@ -0,0 +1,136 @@
+package nats_basic
+
+import (
+	"fmt"  // Import the package for formatted input/output
+	"time" // Import the package for working with time
+
+	"github.com/nats-io/nats.go" // Import the package for working with NATS
+)
+
+// Summary of a stream and its state
+type StreamReport struct {
+	Name      string    `json:"name"`       // Stream name
+	Subjects  []string  `json:"subjects"`   // Subjects captured by the stream
+	Storage   string    `json:"storage"`    // File or memory storage
+	Retention string    `json:"retention"`  // Retention policy
+	Messages  uint64    `json:"messages"`   // Number of stored messages
+	Bytes     uint64    `json:"bytes"`      // Size of the stored messages
+	FirstSeq  uint64    `json:"first_seq"`  // Sequence of the oldest message
+	FirstTime time.Time `json:"first_time"` // Time of the oldest message
+	LastSeq   uint64    `json:"last_seq"`   // Sequence of the newest message
+	LastTime  time.Time `json:"last_time"`  // Time of the newest message
+	Consumers int       `json:"consumers"`  // Number of consumers
+	Created   time.Time `json:"created"`    // Time the stream was created
+}
+
+// Summary of a consumer and its progress
+type ConsumerReport struct {
+	Stream         string    `json:"stream"`          // Stream the consumer reads from
+	Name           string    `json:"name"`            // Consumer name
+	FilterSubject  string    `json:"filter_subject"`  // Subject filter, empty for all subjects
+	AckPolicy      string    `json:"ack_policy"`      // Acknowledgment policy
+	AckWait        string    `json:"ack_wait"`        // How long to wait for an ack before redelivery
+	MaxDeliver     int       `json:"max_deliver"`     // Maximum delivery attempts
+	NumPending     uint64    `json:"num_pending"`     // Messages not yet delivered
+	NumAckPending  int       `json:"num_ack_pending"` // Messages delivered but not acknowledged
+	NumRedelivered int       `json:"num_redelivered"` // Messages delivered more than once
+	NumWaiting     int       `json:"num_waiting"`     // Pull requests waiting for messages
+	DeliveredSeq   uint64    `json:"delivered_seq"`   // Stream sequence of the last delivered message
+	AckFloorSeq    uint64    `json:"ack_floor_seq"`   // Stream sequence below which everything is acknowledged
+	Created        time.Time `json:"created"`         // Time the consumer was created
+}
+
+// Function to summarise a stream
+func ReportStream(info *nats.StreamInfo) StreamReport {
+	return StreamReport{
+		Name:      info.Config.Name,
+		Subjects:  info.Config.Subjects,
+		Storage:   info.Config.Storage.String(),
+		Retention: info.Config.Retention.String(),
+		Messages:  info.State.Msgs,
+		Bytes:     info.State.Bytes,
+		FirstSeq:  info.State.FirstSeq,
+		FirstTime: info.State.FirstTime,
+		LastSeq:   info.State.LastSeq,
+		LastTime:  info.State.LastTime,
+		Consumers: info.State.Consumers,
+		Created:   info.Created,
+	}
+}
+
+// Function to summarise a consumer
+func ReportConsumer(info *nats.ConsumerInfo) ConsumerReport {
+	return ConsumerReport{
+		Stream:         info.Stream,
+		Name:           info.Name,
+		FilterSubject:  info.Config.FilterSubject,
+		AckPolicy:      info.Config.AckPolicy.String(),
+		AckWait:        info.Config.AckWait.String(),
+		MaxDeliver:     info.Config.MaxDeliver,
+		NumPending:     info.NumPending,
+		NumAckPending:  info.NumAckPending,
+		NumRedelivered: info.NumRedelivered,
+		NumWaiting:     info.NumWaiting,
+		DeliveredSeq:   info.Delivered.Stream,
+		AckFloorSeq:    info.AckFloor.Stream,
+		Created:        info.Created,
+	}
+}
+
+// Function to list all streams of the account. The lister of nats.go drops its errors, so the
+// account is queried first and the listed streams are checked against its stream count.
+func ListStreams(js nats.JetStreamContext) ([]StreamReport, error) {
+	account, err := js.AccountInfo()
+	if err != nil {
+		return nil, fmt.Errorf("get account info: %w", err)
+	}
+
+	var reports []StreamReport
+	for info := range js.Streams() {
+		reports = append(reports, ReportStream(info))
+	}
+	if len(reports) < account.Streams {
+		return reports, fmt.Errorf("listed %d of %d streams, the listing was cut short", len(reports), account.Streams)
+	}
+	return reports, nil
+}
+
+// Function to list all consumers of a stream, checked against the consumer count of the stream
+func ListConsumers(js nats.JetStreamContext, stream string) ([]ConsumerReport, error) {
+	info, err := js.StreamInfo(stream)
+	if err != nil {
+		return nil, fmt.Errorf("get stream %s: %w", stream, err)
+	}
+
+	var reports []ConsumerReport
+	for consumer := range js.Consumers(stream) {
+		reports = append(reports, ReportConsumer(consumer))
+	}
+	if len(reports) < info.State.Consumers {
+		return reports, fmt.Errorf("listed %d of %d consumers of %s, the listing was cut short", len(reports), info.State.Consumers, stream)
+	}
+	return reports, nil
+}
+
+// Function to fetch the next message from a durable pull consumer, acknowledging it if asked
+func FetchNext(js nats.JetStreamContext, stream, consumer string, wait time.Duration, ack bool) (*nats.Msg, error) {
+	// Bind to the existing consumer instead of creating a new one
+	sub, err := js.PullSubscribe("", consumer, nats.Bind(stream, consumer))
+	if err != nil {
+		return nil, fmt.Errorf("bind to consumer %s/%s: %w", stream, consumer, err)
+	}
+	defer sub.Unsubscribe() // Stop receiving when the fetch completes
+
+	msgs, err := sub.Fetch(1, nats.MaxWait(wait))
+	if err != nil {
+		return nil, fmt.Errorf("fetch from consumer %s/%s: %w", stream, consumer, err)
+	}
+
+	msg := msgs[0]
+	if ack {
+		if err := msg.AckSync(); err != nil {
+			return nil, fmt.Errorf("ack message: %w", err)
+		}
+	}
+	return msg, nil
+}
@@ -0,0 +1,357 @@
+package nats_basic
+
+import (
+	"context" // Import the package for cancellation and deadlines
+	"errors"  // Import the package for working with errors
+	"fmt"     // Import the package for formatted input/output
+	"io"      // Import the package for the end-of-stream error
+	"strconv" // Import the package for encoding sequence numbers
+	"strings" // Import the package for building example rows
+	"time"    // Import the package for working with time
+
+	"github.com/nats-io/nats.go" // Import the package for working with NATS
+)
+
+// Headers used by streaming replies
+const (
+	StreamSeqHeader    = "Stream-Seq"    // Sequence number of a chunk, starting at 1
+	StreamEndHeader    = "Stream-End"    // Set on the end-of-stream marker
+	StreamErrorHeader  = "Stream-Error"  // Set on the end-of-stream marker when the responder failed
+	StreamAckHeader    = "Stream-Ack"    // Subject the client acknowledges chunks on
+	StreamWindowHeader = "Stream-Window" // Chunks the client accepts before acknowledging
+	StreamCancelHeader = "Stream-Cancel" // Set on an acknowledgement when the client stops reading
+)
+
+// Defaults of streaming replies
+const (
+	DefaultStreamWindow       = 8               // Chunks in flight before the responder waits for acknowledgements
+	DefaultStreamChunkTimeout = 5 * time.Second // Time the client waits for the next chunk
+	DefaultStreamAckTimeout   = 5 * time.Second // Time the responder waits for an acknowledgement
+)
+
+// Errors of streaming replies
+var (
+	ErrChunkTimeout    = errors.New("no chunk received before the timeout")
+	ErrStreamStalled   = errors.New("client stopped acknowledging chunks")
+	ErrStreamCancelled = errors.New("client cancelled the stream")
+	ErrStreamSequence  = errors.New("chunk out of sequence")
+)
+
+// Error sent by a responder that failed part way through a stream
+type StreamError struct {
+	Message string // Description of the failure
+}
+
+// Function to format the stream error
+func (e *StreamError) Error() string {
+	return "stream failed: " + e.Message
+}
+
+// Responder side of a streaming reply. It is not safe for concurrent use.
+type StreamResponder struct {
+	nc         *nats.Conn         // Connection the chunks are sent on
+	reply      string             // Reply inbox of the client
+	acks       *nats.Subscription // Subscription receiving acknowledgements
+	window     int                // Chunks allowed in flight
+	ackTimeout time.Duration      // Time to wait for an acknowledgement
+	sent       int                // Chunks sent
+	acked      int                // Highest chunk acknowledged
+	closed     bool               // Whether the end-of-stream marker was sent
+}
+
+// Function to start a streaming reply to a request; zero ackTimeout uses DefaultStreamAckTimeout
+func NewStreamResponder(nc *nats.Conn, req *nats.Msg, ackTimeout time.Duration) (*StreamResponder, error) {
+	if req.Reply == "" {
+		return nil, errors.New("request has no reply subject")
+	}
+	if ackTimeout <= 0 {
+		ackTimeout = DefaultStreamAckTimeout
+	}
+
+	// The client tells how many chunks it can buffer
+	window, err := strconv.Atoi(req.Header.Get(StreamWindowHeader))
+	if err != nil || window <= 0 {
+		window = DefaultStreamWindow
+	}
+
+	// Acknowledgements come back on a private inbox
+	acks, err := nc.SubscribeSync(nc.NewRespInbox())
+	if err != nil {
+		return nil, fmt.Errorf("subscribe to acknowledgements: %w", err)
+	}
+
+	return &StreamResponder{
+		nc:         nc,
+		reply:      req.Reply,
+		acks:       acks,
+		window:     window,
+		ackTimeout: ackTimeout,
+	}, nil
+}
+
+// Function to send a chunk, waiting first while the client has a full window of unacknowledged chunks
+func (r *StreamResponder) Send(data []byte) error {
+	if r.closed {
+		return errors.New("stream already closed")
+	}
+
+	// Flow control: never get further ahead of the client than its window
+	for r.sent-r.acked >= r.window {
+		if err := r.waitAck(); err != nil {
+			r.abort()
+			return err
+		}
+	}
+
+	r.sent++
+	msg := nats.NewMsg(r.reply)
+	msg.Header.Set(StreamSeqHeader, strconv.Itoa(r.sent))
+	msg.Header.Set(StreamAckHeader, r.acks.Subject)
+	msg.Data = data
+	return r.nc.PublishMsg(msg)
+}
+
+// Function to split a payload into chunks and send them; zero chunkSize fits chunks within the server max payload
+func (r *StreamResponder) SendChunked(data []byte, chunkSize int) error {
+	if chunkSize <= 0 {
+		chunkSize = int(r.nc.MaxPayload()) - 1024 // Leave room for the headers
+	}
+
+	for len(data) > 0 {
+		n := min(chunkSize, len(data))
+		if err := r.Send(data[:n]); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+// Function to send the end-of-stream marker
+func (r *StreamResponder) Close() error {
+	return r.end(nil)
+}
+
+// Function to end the stream with an error the client receives as *StreamError
+func (r *StreamResponder) Fail(err error) error {
+	return r.end(err)
+}
+
+// Function to send the end-of-stream marker, optionally carrying an error
+func (r *StreamResponder) end(failure error) error {
+	if r.closed {
+		return nil
+	}
+	defer r.abort()
+
+	msg := nats.NewMsg(r.reply)
+	msg.Header.Set(StreamSeqHeader, strconv.Itoa(r.sent+1))
+	msg.Header.Set(StreamEndHeader, "true")
+	if failure != nil {
+		msg.Header.Set(StreamErrorHeader, failure.Error())
+	}
+	return r.nc.PublishMsg(msg)
+}
+
+// Function to stop receiving acknowledgements
+func (r *StreamResponder) abort() {
+	r.closed = true
+	r.acks.Unsubscribe()
+}
+
+// Function to wait for the next acknowledgement
+func (r *StreamResponder) waitAck() error {
+	msg, err := r.acks.NextMsg(r.ackTimeout)
+	if errors.Is(err, nats.ErrTimeout) {
+		return fmt.Errorf("chunk %d: %w", r.acked+1, ErrStreamStalled)
+	}
+	if err != nil {
+		return err
+	}
+	if msg.Header.Get(StreamCancelHeader) != "" {
+		return ErrStreamCancelled
+	}
+
+	// Acknowledgements are cumulative, so only the highest one matters
+	if seq, err := strconv.Atoi(string(msg.Data)); err == nil && seq > r.acked {
+		r.acked = seq
+	}
+	return nil
+}
+
+// Settings of a streaming request
+type StreamRequestOptions struct {
+	Window       int           // Chunks the responder may send ahead, zero uses DefaultStreamWindow
+	ChunkTimeout time.Duration // Time to wait for each chunk, zero uses DefaultStreamChunkTimeout
+}
+
+// Client side of a streaming reply, read chunk by chunk with Next
+type ChunkStream struct {
+	ctx     context.Context    // Context cancelling the whole stream
+	nc      *nats.Conn         // Connection acknowledgements are sent on
+	sub     *nats.Subscription // Subscription receiving the chunks
+	timeout time.Duration      // Time to wait for each chunk
+	next    int                // Sequence number of the next expected chunk
+	ack     string             // Subject acknowledgements are sent to
+	err     error              // Error ending the stream, io.EOF after the end marker
+}
+
+// Function to send a request answered by a streaming reply
+func StreamRequest(ctx context.Context, nc *nats.Conn, subject string, data []byte, opts StreamRequestOptions) (*ChunkStream, error) {
+	if opts.Window <= 0 {
+		opts.Window = DefaultStreamWindow
+	}
+	if opts.ChunkTimeout <= 0 {
+		opts.ChunkTimeout = DefaultStreamChunkTimeout
+	}
+
+	// Buffer a whole window of chunks on a private inbox
+	sub, err := nc.SubscribeSync(nc.NewRespInbox())
+	if err != nil {
+		return nil, fmt.Errorf("subscribe to reply inbox: %w", err)
+	}
+	sub.SetPendingLimits(opts.Window+1, -1)
+
+	msg := nats.NewMsg(subject)
+	msg.Reply = sub.Subject
+	msg.Header.Set(StreamWindowHeader, strconv.Itoa(opts.Window))
+	msg.Data = data
+	if err := nc.PublishMsg(msg); err != nil {
+		sub.Unsubscribe()
+		return nil, fmt.Errorf("publish request to %s: %w", subject, err)
+	}
+
+	return &ChunkStream{ctx: ctx, nc: nc, sub: sub, timeout: opts.ChunkTimeout, next: 1}, nil
+}
+
+// Function to get the next chunk. It returns io.EOF after the end marker, *StreamError when the
+// responder failed and an error wrapping ErrChunkTimeout when the next chunk is late.
+func (s *ChunkStream) Next() ([]byte, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, s.timeout)
+	defer cancel()
+
+	msg, err := s.sub.NextMsgWithContext(ctx)
+	switch {
+	case err == nil:
+	case errors.Is(err, context.DeadlineExceeded) && s.ctx.Err() == nil:
+		return nil, s.fail(fmt.Errorf("chunk %d: %w", s.next, ErrChunkTimeout))
+	default:
+		return nil, s.fail(err)
+	}
+
+	if s.next == 1 && isNoResponders(msg) {
+		return nil, s.fail(nats.ErrNoResponders)
+	}
+
+	// Chunks come from a single publisher, so a gap means some were dropped
+	if seq, _ := strconv.Atoi(msg.Header.Get(StreamSeqHeader)); seq != s.next {
+		return nil, s.fail(fmt.Errorf("expected chunk %d, got %d: %w", s.next, seq, ErrStreamSequence))
+	}
+
+	if msg.Header.Get(StreamEndHeader) != "" {
+		if message := msg.Header.Get(StreamErrorHeader); message != "" {
+			return nil, s.fail(&StreamError{Message: message})
+		}
+		return nil, s.fail(io.EOF)
+	}
+
+	// Acknowledge the chunk so the responder can send more
+	s.ack = msg.Header.Get(StreamAckHeader)
+	if s.ack != "" {
+		s.nc.Publish(s.ack, []byte(strconv.Itoa(s.next)))
+	}
+	s.next++
+	return msg.Data, nil
+}
+
+// Function to stop reading; the responder is told to stop sending if the stream has not ended
+func (s *ChunkStream) Close() error {
+	if s.err != nil {
+		return nil // The stream already ended
+	}
+
+	if s.ack != "" {
+		msg := nats.NewMsg(s.ack)
+		msg.Header.Set(StreamCancelHeader, "true")
+		s.nc.PublishMsg(msg)
+	}
+	s.err = ErrStreamCancelled
+	return s.sub.Unsubscribe()
+}
+
+// Function to end the stream with an error and stop receiving chunks
+func (s *ChunkStream) fail(err error) error {
+	s.err = err
+	s.sub.Unsubscribe()
+	return err
+}
+
+// Function to demonstrate a query answered by a streaming reply
+func StreamReplyExample() {
+	// Log a message about launching the streaming reply example
+	logger().Info("Example of using streaming replies NATS")
+
+	// Connect to NATS server
+	nc, closeConn := connectExample("stream-reply-example")
+	defer closeConn() // Drain and close the connection when the function completes
+
+	logger().Info("Connected to NATS server") // Message about successful connection
+
+	// Setup a responder streaming the rows of a report
+	_, err := nc.Subscribe("reports.rows", func(m *nats.Msg) {
+		stream, err := NewStreamResponder(nc, m, 0)
+		if err != nil {
+			logger().Error("Error starting the stream", append(msgAttrs(m), "error", err)...) // Log an error if the stream cannot start
+			return
+		}
+
+		// Send each row in its own chunk, then a large footer split into chunks
+		for i := 1; i <= 5; i++ {
+			if err := stream.Send([]byte(fmt.Sprintf("row %d of %s", i, string(m.Data)))); err != nil {
+				logger().Warn("Error sending the stream", append(msgAttrs(m), "error", err)...) // Log an error if the client went away
+				return
+			}
+		}
+		if err := stream.SendChunked([]byte(strings.Repeat("x", 2500)), 1000); err != nil {
+			logger().Warn("Error sending the stream", append(msgAttrs(m), "error", err)...) // Log an error if the client went away
+			return
+		}
+		stream.Close() // Send the end-of-stream marker
+	})
+	if err != nil {
+		fatal("Error setting up the responder", err) // Log an error if setting up the responder fails
+	}
+
+	logger().Info("Report responder set up") // Message about successful responder setup
+
+	// Request the report, letting the responder run at most 2 chunks ahead
+	stream, err := StreamRequest(context.Background(), nc, "reports.rows", []byte("sales"), StreamRequestOptions{
+		Window:       2,
+		ChunkTimeout: time.Second,
+	})
+	if err != nil {
+		fatal("Error sending the request", err) // Log an error if sending the request fails
+	}
+	defer stream.Close() // Stop reading when the function completes
+
+	// Read the chunks until the end-of-stream marker
+	for {
+		chunk, err := stream.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fatal("Error reading the stream", err) // Log an error if the stream fails
+		}
+		if len(chunk) > 40 {
+			logger().Info("Received chunk", "subject", "reports.rows", "bytes", len(chunk)) // Log the size of a large chunk
+		} else {
+			logger().Info("Received chunk", "subject", "reports.rows", "data", string(chunk)) // Log a small chunk
+		}
+	}
+	logger().Info("Report complete") // Message about the end of the stream
+}
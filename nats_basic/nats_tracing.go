@@ -0,0 +1,390 @@
+package nats_basic
+
+import (
+	"context"       // Import the package for carrying the trace context
+	"crypto/rand"   // Import the package for generating trace and span IDs
+	"encoding/hex"  // Import the package for formatting IDs
+	"encoding/json" // Import the package for exporting spans as JSON
+	"errors"        // Import the package for working with errors
+	"fmt"           // Import the package for formatted input/output
+	"io"            // Import the package for writing exported spans
+	"os"            // Import the package for opening the export file
+	"strings"       // Import the package for parsing the traceparent header
+	"sync"          // Import the package for protecting the exporter
+	"time"          // Import the package for working with time
+
+	"github.com/nats-io/nats.go" // Import the package for working with NATS
+)
+
+// W3C trace context headers carried in NATS message headers
+const (
+	TraceparentHeader = "traceparent" // Version, trace ID, parent span ID and flags
+	TracestateHeader  = "tracestate"  // Vendor-specific trace state, passed through unchanged
+)
+
+// Error returned when a traceparent header cannot be parsed
+var ErrInvalidTraceparent = errors.New("invalid traceparent")
+
+// W3C trace context identifying a span and its trace
+type TraceContext struct {
+	TraceID [16]byte // ID shared by every span of the trace
+	SpanID  [8]byte  // ID of the span
+	Sampled bool     // Whether the trace is recorded
+	State   string   // Tracestate header value
+}
+
+// Function to check whether the trace context has non-zero IDs
+func (tc TraceContext) IsValid() bool {
+	return tc.TraceID != [16]byte{} && tc.SpanID != [8]byte{}
+}
+
+// Function to format the trace context as a traceparent header value
+func (tc TraceContext) Traceparent() string {
+	flags := "00"
+	if tc.Sampled {
+		flags = "01"
+	}
+	return "00-" + hex.EncodeToString(tc.TraceID[:]) + "-" + hex.EncodeToString(tc.SpanID[:]) + "-" + flags
+}
+
+// Function to parse a traceparent header value. As the W3C specification requires, every field is
+// lowercase hex, version ff is invalid and later versions may append fields after the flags.
+func ParseTraceparent(value string) (TraceContext, error) {
+	var tc TraceContext
+
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || !isLowerHex(parts[0], 2) || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return tc, fmt.Errorf("%w: %q", ErrInvalidTraceparent, value)
+	}
+	if !isLowerHex(parts[1], 2*len(tc.TraceID)) {
+		return tc, fmt.Errorf("%w: trace ID %q", ErrInvalidTraceparent, parts[1])
+	}
+	if !isLowerHex(parts[2], 2*len(tc.SpanID)) {
+		return tc, fmt.Errorf("%w: span ID %q", ErrInvalidTraceparent, parts[2])
+	}
+	if !isLowerHex(parts[3], 2) {
+		return tc, fmt.Errorf("%w: flags %q", ErrInvalidTraceparent, parts[3])
+	}
+
+	// The fields are valid hex, so decoding cannot fail
+	traceID, _ := hex.DecodeString(parts[1])
+	spanID, _ := hex.DecodeString(parts[2])
+	flags, _ := hex.DecodeString(parts[3])
+
+	copy(tc.TraceID[:], traceID)
+	copy(tc.SpanID[:], spanID)
+	tc.Sampled = flags[0]&1 == 1
+	if !tc.IsValid() {
+		return tc, fmt.Errorf("%w: zero ID in %q", ErrInvalidTraceparent, value)
+	}
+	return tc, nil
+}
+
+// Function to check whether s consists of exactly n lowercase hex digits
+func isLowerHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// Function to write the trace context of ctx into message headers
+func InjectTraceContext(ctx context.Context, header nats.Header) {
+	tc, ok := TraceContextFrom(ctx)
+	if !ok {
+		return
+	}
+	header.Set(TraceparentHeader, tc.Traceparent())
+	if tc.State != "" {
+		header.Set(TracestateHeader, tc.State)
+	}
+}
+
+// Function to read the trace context from message headers
+func ExtractTraceContext(header nats.Header) (TraceContext, bool) {
+	tc, err := ParseTraceparent(header.Get(TraceparentHeader))
+	if err != nil {
+		return TraceContext{}, false
+	}
+	tc.State = header.Get(TracestateHeader)
+	return tc, true
+}
+
+// Key of the trace context in a context
+type traceContextKey struct{}
+
+// Function to store a trace context in a context
+func ContextWithTraceContext(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, tc)
+}
+
+// Function to get the trace context stored in a context
+func TraceContextFrom(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(traceContextKey{}).(TraceContext)
+	return tc, ok && tc.IsValid()
+}
+
+// Role of a span, following the OpenTelemetry span kinds
+type SpanKind string
+
+const (
+	SpanKindInternal SpanKind = "internal" // Work inside a process
+	SpanKindProducer SpanKind = "producer" // Publishing a message
+	SpanKindConsumer SpanKind = "consumer" // Handling a published message
+	SpanKindClient   SpanKind = "client"   // Sending a request
+	SpanKindServer   SpanKind = "server"   // Handling a request
+)
+
+// Finished span as passed to exporters
+type SpanData struct {
+	Name         string            `json:"name"`                     // Operation name, e.g. "publish orders.1"
+	Kind         SpanKind          `json:"kind"`                     // Role of the span
+	TraceID      string            `json:"trace_id"`                 // Hex trace ID
+	SpanID       string            `json:"span_id"`                  // Hex span ID
+	ParentSpanID string            `json:"parent_span_id,omitempty"` // Hex ID of the parent span, empty for a root span
+	Start        time.Time         `json:"start"`                    // Start time
+	End          time.Time         `json:"end"`                      // End time
+	Duration     time.Duration     `json:"duration"`                 // Time between start and end
+	Attributes   map[string]string `json:"attributes,omitempty"`     // Attributes such as the subject
+	Error        string            `json:"error,omitempty"`          // Error the operation ended with
+}
+
+// Receiver of finished spans
+type SpanExporter interface {
+	ExportSpan(span SpanData) error
+}
+
+// Span being recorded; End exports it when its trace is sampled
+type Span struct {
+	tracer *Tracer      // Tracer exporting the span
+	tc     TraceContext // Trace context of the span
+	once   sync.Once    // Makes End idempotent
+
+	mu   sync.Mutex // Protects the data
+	data SpanData   // Recorded data
+}
+
+// Function to get the trace context of the span
+func (s *Span) TraceContext() TraceContext {
+	return s.tc
+}
+
+// Function to set an attribute of the span
+func (s *Span) SetAttribute(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Attributes[key] = value
+}
+
+// Function to end the span with an optional error and export it. Spans of unsampled traces are
+// dropped, but their trace context is still propagated so downstream services make the same decision.
+func (s *Span) End(err error) {
+	s.once.Do(func() {
+		if !s.tc.Sampled {
+			return
+		}
+
+		s.mu.Lock()
+		s.data.End = time.Now()
+		s.data.Duration = s.data.End.Sub(s.data.Start)
+		if err != nil {
+			s.data.Error = err.Error()
+		}
+		data := s.data
+		s.mu.Unlock()
+
+		s.tracer.export(data)
+	})
+}
+
+// Tracer creating spans and exporting them when they end
+type Tracer struct {
+	mu       sync.RWMutex // Protects the exporter
+	exporter SpanExporter // Exporter of finished spans, nil drops them
+}
+
+// Function to create a tracer; a nil exporter drops the spans
+func NewTracer(exporter SpanExporter) *Tracer {
+	return &Tracer{exporter: exporter}
+}
+
+// Function to replace the exporter; nil drops the spans
+func (t *Tracer) SetExporter(exporter SpanExporter) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.exporter = exporter
+}
+
+// Function to start a span as a child of the trace context in ctx, or as a new trace
+func (t *Tracer) StartSpan(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	parent, ok := TraceContextFrom(ctx)
+	return t.start(ctx, parent, ok, name, kind)
+}
+
+// Function to start a span handling a message, as a child of the trace context in its headers
+func (t *Tracer) StartMsgSpan(ctx context.Context, msg *nats.Msg, kind SpanKind) (context.Context, *Span) {
+	parent, ok := ExtractTraceContext(msg.Header)
+	ctx, span := t.start(ctx, parent, ok, "handle "+msg.Subject, kind)
+	span.setMsgAttributes(msg)
+	return ctx, span
+}
+
+// Function to create a span and store its trace context in ctx
+func (t *Tracer) start(ctx context.Context, parent TraceContext, hasParent bool, name string, kind SpanKind) (context.Context, *Span) {
+	tc := TraceContext{Sampled: true}
+	if hasParent {
+		tc.TraceID, tc.Sampled, tc.State = parent.TraceID, parent.Sampled, parent.State
+	} else {
+		rand.Read(tc.TraceID[:])
+	}
+	rand.Read(tc.SpanID[:])
+
+	span := &Span{
+		tracer: t,
+		tc:     tc,
+		data: SpanData{
+			Name:       name,
+			Kind:       kind,
+			TraceID:    hex.EncodeToString(tc.TraceID[:]),
+			SpanID:     hex.EncodeToString(tc.SpanID[:]),
+			Start:      time.Now(),
+			Attributes: map[string]string{"messaging.system": "nats"},
+		},
+	}
+	if hasParent {
+		span.data.ParentSpanID = hex.EncodeToString(parent.SpanID[:])
+	}
+	return ContextWithTraceContext(ctx, tc), span
+}
+
+// Function to record the subject and, for JetStream messages, the stream position of a message
+func (s *Span) setMsgAttributes(msg *nats.Msg) {
+	s.SetAttribute("messaging.destination", msg.Subject)
+	if !strings.HasPrefix(msg.Reply, "$JS.ACK.") {
+		return
+	}
+	if meta, err := msg.Metadata(); err == nil {
+		s.SetAttribute("messaging.nats.stream", meta.Stream)
+		s.SetAttribute("messaging.nats.consumer", meta.Consumer)
+		s.SetAttribute("messaging.nats.sequence", fmt.Sprint(meta.Sequence.Stream))
+		s.SetAttribute("messaging.nats.delivery_count", fmt.Sprint(meta.NumDelivered))
+	}
+}
+
+// Function to pass a finished span to the exporter
+func (t *Tracer) export(span SpanData) {
+	t.mu.RLock()
+	exporter := t.exporter
+	t.mu.RUnlock()
+
+	if exporter == nil {
+		return
+	}
+	if err := exporter.ExportSpan(span); err != nil {
+		logger().Warn("Error exporting span", "span", span.Name, "trace_id", span.TraceID, "error", err)
+	}
+}
+
+// Function to publish a message in a producer span, carrying the trace context in its headers
+func (t *Tracer) Publish(ctx context.Context, nc *nats.Conn, subject string, data []byte) error {
+	ctx, span := t.StartSpan(ctx, "publish "+subject, SpanKindProducer)
+	span.SetAttribute("messaging.destination", subject)
+
+	msg := nats.NewMsg(subject)
+	msg.Data = data
+	InjectTraceContext(ctx, msg.Header)
+
+	err := nc.PublishMsg(msg)
+	span.End(err)
+	return err
+}
+
+// Function to publish a message to a stream in a producer span, carrying the trace context in its headers
+func (t *Tracer) PublishJS(ctx context.Context, js nats.JetStreamContext, subject string, data []byte) (*nats.PubAck, error) {
+	ctx, span := t.StartSpan(ctx, "publish "+subject, SpanKindProducer)
+	span.SetAttribute("messaging.destination", subject)
+
+	msg := nats.NewMsg(subject)
+	msg.Data = data
+	InjectTraceContext(ctx, msg.Header)
+
+	ack, err := js.PublishMsg(msg)
+	if err == nil {
+		span.SetAttribute("messaging.nats.stream", ack.Stream)
+		span.SetAttribute("messaging.nats.sequence", fmt.Sprint(ack.Sequence))
+	}
+	span.End(err)
+	return ack, err
+}
+
+// Function to send a request in a client span, carrying the trace context in its headers
+func (t *Tracer) Request(ctx context.Context, nc *nats.Conn, subject string, data []byte) (*nats.Msg, error) {
+	ctx, span := t.StartSpan(ctx, "request "+subject, SpanKindClient)
+	span.SetAttribute("messaging.destination", subject)
+
+	msg := nats.NewMsg(subject)
+	msg.Data = data
+	InjectTraceContext(ctx, msg.Header)
+
+	reply, err := nc.RequestMsgWithContext(ctx, msg)
+	span.End(err)
+	return reply, err
+}
+
+// Function to wrap a message handler in a span continuing the trace of the message; requests
+// get a server span and published messages a consumer span
+func (t *Tracer) Handler(handler func(ctx context.Context, msg *nats.Msg)) nats.MsgHandler {
+	return func(msg *nats.Msg) {
+		kind := SpanKindConsumer
+		if msg.Reply != "" && !strings.HasPrefix(msg.Reply, "$JS.ACK.") {
+			kind = SpanKindServer
+		}
+		ctx, span := t.StartMsgSpan(context.Background(), msg, kind)
+		defer span.End(nil)
+		handler(ctx, msg)
+	}
+}
+
+// Exporter writing each span as a line of JSON, e.g. to standard output or a file
+type JSONSpanExporter struct {
+	mu     sync.Mutex    // Serializes writes
+	enc    *json.Encoder // Encoder writing the spans
+	closer io.Closer     // File closed by Close, nil for writers owned by the caller
+}
+
+// Function to create an exporter writing to w
+func NewJSONSpanExporter(w io.Writer) *JSONSpanExporter {
+	return &JSONSpanExporter{enc: json.NewEncoder(w)}
+}
+
+// Function to create an exporter appending to a file, created when missing
+func OpenJSONSpanExporter(path string) (*JSONSpanExporter, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open span file: %w", err)
+	}
+	return &JSONSpanExporter{enc: json.NewEncoder(f), closer: f}, nil
+}
+
+// Function to write a span as a line of JSON
+func (e *JSONSpanExporter) ExportSpan(span SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.enc.Encode(span)
+}
+
+// Function to close the file of the exporter, if it opened one
+func (e *JSONSpanExporter) Close() error {
+	if e.closer == nil {
+		return nil
+	}
+	return e.closer.Close()
+}
+
+// Tracer shared by the examples, exporting nothing until main sets an exporter from TRACE_OUTPUT
+var DefaultTracer = NewTracer(nil)
@@ -0,0 +1,162 @@
+package nats_basic
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+)
+
+func TestParseTraceparentAcceptsValidHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		traceID string
+		spanID  string
+		sampled bool
+	}{
+		{"sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", true},
+		{"not sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", false},
+		{"other flags set", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-03", "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", true},
+		{"surrounding spaces", " 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01 ", "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", true},
+		{"later version", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", true},
+		{"later version with extra field", "cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-what-the-future", "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc, err := ParseTraceparent(tt.value)
+			if err != nil {
+				t.Fatalf("ParseTraceparent(%q): %v", tt.value, err)
+			}
+			if got := tc.Traceparent(); got[3:35] != tt.traceID || got[36:52] != tt.spanID {
+				t.Errorf("parsed IDs %s, want trace %s and span %s", got, tt.traceID, tt.spanID)
+			}
+			if tc.Sampled != tt.sampled {
+				t.Errorf("sampled %v, want %v", tc.Sampled, tt.sampled)
+			}
+		})
+	}
+}
+
+func TestParseTraceparentRejectsInvalidHeaders(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"empty", ""},
+		{"too few fields", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7"},
+		{"extra field in version 00", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"},
+		{"forbidden version", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{"non-hex version", "zz-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{"uppercase version", "0A-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{"short version", "0-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{"uppercase trace ID", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01"},
+		{"uppercase span ID", "00-4bf92f3577b34da6a3ce929d0e0e4736-00F067AA0BA902B7-01"},
+		{"uppercase flags", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-0A"},
+		{"short trace ID", "00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01"},
+		{"long span ID", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b700-01"},
+		{"non-hex trace ID", "00-4bf92f3577b34da6a3ce929d0e0e47zz-00f067aa0ba902b7-01"},
+		{"non-hex flags", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-0g"},
+		{"zero trace ID", "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		{"zero span ID", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseTraceparent(tt.value); !errors.Is(err, ErrInvalidTraceparent) {
+				t.Errorf("ParseTraceparent(%q) returned %v, want %v", tt.value, err, ErrInvalidTraceparent)
+			}
+		})
+	}
+}
+
+func TestTraceContextRoundTrip(t *testing.T) {
+	for _, sampled := range []bool{true, false} {
+		want := TraceContext{
+			TraceID: [16]byte{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+			SpanID:  [8]byte{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+			Sampled: sampled,
+			State:   "vendor=value",
+		}
+
+		header := nats.Header{}
+		InjectTraceContext(ContextWithTraceContext(context.Background(), want), header)
+		got, ok := ExtractTraceContext(header)
+		if !ok {
+			t.Fatalf("ExtractTraceContext found no trace context in %v", header)
+		}
+		if got != want {
+			t.Errorf("round trip gave %+v, want %+v", got, want)
+		}
+	}
+}
+
+func TestInjectWithoutTraceContext(t *testing.T) {
+	header := nats.Header{}
+	InjectTraceContext(context.Background(), header)
+	if len(header) != 0 {
+		t.Errorf("headers %v set without a trace context", header)
+	}
+	if _, ok := ExtractTraceContext(header); ok {
+		t.Error("ExtractTraceContext found a trace context in empty headers")
+	}
+}
+
+// Exporter keeping the exported spans
+type recordingExporter struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+func (e *recordingExporter) ExportSpan(span SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, span)
+	return nil
+}
+
+func TestSpanEndExportsOnlySampledSpans(t *testing.T) {
+	tests := []struct {
+		name    string
+		sampled bool
+		want    int
+	}{
+		{"sampled parent", true, 1},
+		{"unsampled parent", false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := &recordingExporter{}
+			tracer := NewTracer(exporter)
+			parent := TraceContext{TraceID: [16]byte{1}, SpanID: [8]byte{2}, Sampled: tt.sampled}
+
+			ctx, span := tracer.StartSpan(ContextWithTraceContext(context.Background(), parent), "work", SpanKindInternal)
+			span.End(nil)
+			span.End(nil) // Ending twice exports at most once
+
+			if len(exporter.spans) != tt.want {
+				t.Errorf("exported %d spans, want %d", len(exporter.spans), tt.want)
+			}
+
+			// The sampling decision is passed on to children
+			child, ok := TraceContextFrom(ctx)
+			if !ok || child.Sampled != tt.sampled || child.TraceID != parent.TraceID {
+				t.Errorf("child trace context %+v does not continue %+v", child, parent)
+			}
+		})
+	}
+}
+
+func TestNewTraceIsSampled(t *testing.T) {
+	exporter := &recordingExporter{}
+	_, span := NewTracer(exporter).StartSpan(context.Background(), "root", SpanKindInternal)
+	span.End(errors.New("failed"))
+
+	if len(exporter.spans) != 1 {
+		t.Fatalf("exported %d spans, want 1", len(exporter.spans))
+	}
+	if got := exporter.spans[0]; got.ParentSpanID != "" || got.Error != "failed" {
+		t.Errorf("root span %+v, want no parent and the error", got)
+	}
+}